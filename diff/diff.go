@@ -0,0 +1,268 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+type Edit struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxEdits bounds the Myers search. Inputs that differ by more than this
+// are reported as a whole delete followed by a whole insert rather than
+// spending quadratic memory on the exact script.
+const maxEdits = 1000
+
+// Lines diffs a and b line by line. Each edit's Text is one line without its
+// trailing newline.
+func Lines(a, b string) []Edit {
+	return compute(splitLines(a), splitLines(b))
+}
+
+// Words diffs a and b at word granularity. Whitespace is kept as separate
+// tokens and consecutive edits with the same op are merged, so joining the
+// Text of every non-insert edit reproduces a exactly.
+func Words(a, b string) []Edit {
+	edits := compute(splitWords(a), splitWords(b))
+
+	var merged []Edit
+	for _, e := range edits {
+		if n := len(merged); n > 0 && merged[n-1].Op == e.Op {
+			merged[n-1].Text += e.Text
+			continue
+		}
+		merged = append(merged, e)
+	}
+	return merged
+}
+
+// Unified renders a line diff in the unified format used by diff -u, with
+// the given number of context lines around each change.
+func Unified(fromName, toName, a, b string, context int) string {
+	edits := Lines(a, b)
+
+	changed := false
+	for _, e := range edits {
+		if e.Op != OpEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// aLine/bLine hold the 1-based line numbers each edit starts at.
+	aLine := make([]int, len(edits)+1)
+	bLine := make([]int, len(edits)+1)
+	aLine[0], bLine[0] = 1, 1
+	for i, e := range edits {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if e.Op != OpInsert {
+			aLine[i+1]++
+		}
+		if e.Op != OpDelete {
+			bLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].Op == OpEqual {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := i
+		// Extend the hunk while the next change is within 2*context lines.
+		for end < len(edits) {
+			if edits[end].Op != OpEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].Op == OpEqual {
+				run++
+			}
+			if run == len(edits) || run-end > 2*context {
+				end = min(end+context, len(edits))
+				break
+			}
+			end = run
+		}
+
+		aCount := aLine[end] - aLine[start]
+		bCount := bLine[end] - bLine[start]
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine[start], aCount), hunkRange(bLine[start], bCount))
+		for _, e := range edits[start:end] {
+			switch e.Op {
+			case OpEqual:
+				out.WriteString(" ")
+			case OpDelete:
+				out.WriteString("-")
+			case OpInsert:
+				out.WriteString("+")
+			}
+			out.WriteString(e.Text)
+			out.WriteString("\n")
+		}
+
+		i = end
+	}
+
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func splitWords(s string) []string {
+	var tokens []string
+	start := 0
+	var prev rune
+	for i, r := range s {
+		if i > start && (unicode.IsSpace(prev) != unicode.IsSpace(r) || isPunct(r) || isPunct(prev)) {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		prev = r
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func compute(a, b []string) []Edit {
+	// Trim the common prefix and suffix; the search only has to cover the
+	// part in between.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []Edit
+	for _, t := range a[:prefix] {
+		edits = append(edits, Edit{OpEqual, t})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, t := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{OpEqual, t})
+	}
+	return edits
+}
+
+// myers finds a shortest edit script with the greedy algorithm from
+// "An O(ND) Difference Algorithm and Its Variations" (Myers, 1986).
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		edits := make([]Edit, 0, n+m)
+		for _, t := range a {
+			edits = append(edits, Edit{OpDelete, t})
+		}
+		for _, t := range b {
+			edits = append(edits, Edit{OpInsert, t})
+		}
+		return edits
+	}
+
+	var reversed []Edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Edit{OpEqual, a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, Edit{OpInsert, b[prevY]})
+			} else {
+				reversed = append(reversed, Edit{OpDelete, a[prevX]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	edits := make([]Edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"test-ai-api/init/db"
+	"test-ai-api/site"
+	"test-ai-api/storage"
+	"test-ai-api/stores"
+	"time"
+)
+
+func runExportStatic(args []string) error {
+	flags := flag.NewFlagSet("export-static", flag.ContinueOnError)
+	out := flags.String("out", "public", "directory to write the site into")
+	siteURL := flags.String("url", "", "public URL of the site (default $SITE_URL)")
+	images := flags.String("images", "", "directory that site-relative image URLs outside /media/ are read from")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	siteConfig := site.ConfigFromEnv()
+	if *siteURL != "" {
+		siteConfig.URL = strings.TrimSuffix(*siteURL, "/")
+	}
+	if siteConfig.URL == "" {
+		return fmt.Errorf("export-static needs the site URL: pass -url or set SITE_URL")
+	}
+	theme, err := site.LoadTheme(siteConfig.ThemeDir)
+	if err != nil {
+		return err
+	}
+
+	cfg := db.ConfigFromEnv()
+	d, err := cfg.Dialect()
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := stores.VerifySchema(database); err != nil {
+		return err
+	}
+
+	blobs, err := storage.Open(storage.ConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	imageFS := mediaFS{store: stores.NewImageStore(database, d, blobs), blobs: blobs}
+	if *images != "" {
+		imageFS.fallback = os.DirFS(*images)
+	}
+
+	s := site.New(siteConfig, theme,
+		stores.NewArticleStore(database, d), stores.NewAuthorStore(database, d),
+		stores.NewTagStore(database, d), stores.NewSitemapStore(database, d),
+	)
+	stats, err := s.Export(*out, imageFS)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range stats.MissingImages {
+		fmt.Fprintf(os.Stderr, "missing image %s\n", name)
+	}
+	fmt.Printf("%s: %d written, %d unchanged, %d removed\n", *out, stats.Written, stats.Unchanged, stats.Removed)
+	return nil
+}
+
+// mediaFS lets the export read uploaded images and their generated
+// variants by their /media/ paths straight from blob storage. Other paths
+// go to fallback, if there is one.
+type mediaFS struct {
+	store    stores.ImageRepository
+	blobs    storage.BlobStorage
+	fallback fs.FS
+}
+
+func (m mediaFS) Open(name string) (fs.File, error) {
+	parts := strings.Split(name, "/")
+	if (len(parts) != 3 && len(parts) != 4) || parts[0] != "media" {
+		if m.fallback == nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return m.fallback.Open(name)
+	}
+
+	notFound := &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, notFound
+	}
+	image, err := m.store.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && image.StorageKey == "") {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := image.StorageKey
+	if len(parts) == 4 {
+		width, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, notFound
+		}
+		key = stores.VariantKey(image, width)
+	}
+
+	blob, err := m.blobs.Open(context.Background(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return blobFile{Blob: blob, name: path.Base(name)}, nil
+}
+
+// blobFile adapts an open blob to fs.File.
+type blobFile struct {
+	*storage.Blob
+	name string
+}
+
+func (f blobFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (f blobFile) Name() string               { return f.name }
+func (f blobFile) Size() int64                { return f.Blob.Size }
+func (f blobFile) Mode() fs.FileMode          { return 0o444 }
+func (f blobFile) ModTime() time.Time         { return f.Blob.ModTime }
+func (f blobFile) IsDir() bool                { return false }
+func (f blobFile) Sys() any                   { return nil }
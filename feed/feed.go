@@ -0,0 +1,308 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Feed is a format-neutral feed. URLs are absolute and Updated is the most
+// recent change to any item.
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the page the feed mirrors.
+	Link    string
+	Updated time.Time
+	Items   []Item
+}
+
+type Item struct {
+	// ID identifies the item for good, even if its Link changes.
+	ID    string
+	Title string
+	Link  string
+	// Summary is plain text. Content is HTML and may be empty, in which
+	// case readers only get the summary.
+	Summary    string
+	Content    string
+	AuthorName string
+	AuthorURL  string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+type Format int
+
+const (
+	RSS Format = iota
+	Atom
+	JSON
+)
+
+func (f Format) ContentType() string {
+	switch f {
+	case Atom:
+		return "application/atom+xml; charset=utf-8"
+	case JSON:
+		return "application/feed+json; charset=utf-8"
+	default:
+		return "application/rss+xml; charset=utf-8"
+	}
+}
+
+// Encode renders f in the given format. self is the URL the encoded feed
+// will be served from.
+func Encode(format Format, f Feed, self string) ([]byte, error) {
+	switch format {
+	case RSS:
+		return encodeXML(rssFeed(f, self))
+	case Atom:
+		return encodeXML(atomFeed(f, self))
+	case JSON:
+		return encodeJSON(jsonFeed(f, self))
+	default:
+		return nil, fmt.Errorf("unknown feed format %d", format)
+	}
+}
+
+func encodeXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// encodeJSON leaves <, > and & alone; content_html is full of them and
+// nothing downstream mistakes a feed for HTML.
+func encodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RSS 2.0, with the content module for full text, Dublin Core for the
+// author name (RSS's own author element wants an email address) and an
+// Atom self link as the RSS Advisory Board recommends.
+
+type rss struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	AtomNS       string     `xml:"xmlns:atom,attr"`
+	ContentNS    string     `xml:"xmlns:content,attr"`
+	DublinCoreNS string     `xml:"xmlns:dc,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rssFeed(f Feed, self string) rss {
+	channel := rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		Self:        atomLink{Href: self, Rel: "self", Type: "application/rss+xml"},
+		Items:       make([]rssItem, len(f.Items)),
+	}
+	if !f.Updated.IsZero() {
+		channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for i, item := range f.Items {
+		channel.Items[i] = rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{Value: item.ID},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+			Creator:     item.AuthorName,
+			Categories:  item.Categories,
+			Description: item.Summary,
+			Content:     item.Content,
+		}
+	}
+	return rss{
+		Version:      "2.0",
+		AtomNS:       "http://www.w3.org/2005/Atom",
+		ContentNS:    "http://purl.org/rss/1.0/modules/content/",
+		DublinCoreNS: "http://purl.org/dc/elements/1.1/",
+		Channel:      channel,
+	}
+}
+
+// Atom (RFC 4287).
+
+type atom struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func atomFeed(f Feed, self string) atom {
+	feed := atom{
+		Title:    f.Title,
+		Subtitle: f.Description,
+		ID:       f.Link,
+		Updated:  atomTime(f.Updated),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: self, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, len(f.Items)),
+	}
+	for i, item := range f.Items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Link:      atomLink{Href: item.Link, Rel: "alternate", Type: "text/html"},
+			Published: atomTime(item.Published),
+			Updated:   atomTime(item.Updated),
+		}
+		if item.AuthorName != "" {
+			entry.Author = &atomAuthor{Name: item.AuthorName, URI: item.AuthorURL}
+		}
+		for _, c := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		feed.Entries[i] = entry
+	}
+	return feed
+}
+
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// JSON Feed 1.1 (https://jsonfeed.org/version/1.1).
+
+type jsonFeedDoc struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	ContentText   string           `json:"content_text,omitempty"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+func jsonFeed(f Feed, self string) jsonFeedDoc {
+	doc := jsonFeedDoc{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     self,
+		Description: f.Description,
+		Items:       make([]jsonFeedItem, len(f.Items)),
+	}
+	for i, item := range f.Items {
+		entry := jsonFeedItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			Summary:       item.Summary,
+			DatePublished: atomTime(item.Published),
+			DateModified:  atomTime(item.Updated),
+			Tags:          item.Categories,
+		}
+		// Every item needs either content_html or content_text.
+		if item.Content != "" {
+			entry.ContentHTML = item.Content
+		} else {
+			entry.ContentText = item.Summary
+		}
+		if item.AuthorName != "" {
+			entry.Authors = []jsonFeedAuthor{{Name: item.AuthorName, URL: item.AuthorURL}}
+		}
+		doc.Items[i] = entry
+	}
+	return doc
+}
@@ -1,6 +1,6 @@
 import { defineStore } from 'pinia'
 import axios from 'axios'
-import type { Article } from '../types'
+import type { Article, Page } from '../types'
 
 interface ArticlesState {
   articles: Article[]
@@ -22,8 +22,8 @@ export const useArticlesStore = defineStore('articles', {
       this.loading = true
       this.error = null
       try {
-        const response = await axios.get<Article[]>('/api/articles')
-        this.articles = response.data
+        const response = await axios.get<Page<Article>>('/api/articles')
+        this.articles = response.data.data
       } catch (error: any) {
         this.error = error.response?.data?.error || 'Failed to fetch articles'
         throw this.error
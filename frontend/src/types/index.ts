@@ -27,6 +27,7 @@ export interface Author {
   created_at: string
   updated_at: string
   deleted_at?: string
+  version: number
 }
 
 export interface Article {
@@ -35,22 +36,71 @@ export interface Article {
   slug: string
   short_description: string
   content: string
+  content_html: string
   status: string
   author_id: number
   author?: Author
+  tags: Tag[]
+  categories: Category[]
   published_at?: string
   created_at: string
   updated_at: string
   deleted_at?: string
+  version: number
+}
+
+export interface Tag {
+  id: number
+  name: string
+  slug: string
+  article_count?: number
+  created_at: string
+  updated_at: string
+}
+
+export interface Category {
+  id: number
+  name: string
+  slug: string
+  description: string
+  article_count?: number
+  created_at: string
+  updated_at: string
+}
+
+export interface Page<T> {
+  data: T[]
+  total: number
+  page?: number
+  per_page: number
+  next_cursor?: string
+  prev_cursor?: string
+  links: {
+    next?: string
+    prev?: string
+  }
 }
 
 export interface Image {
   id: number
   url: string
+  filename?: string
+  size?: number
+  mime_type?: string
+  width?: number
+  height?: number
+  variants?: ImageVariant[]
+  srcset?: string
   created_at: string
   deleted_at?: string
 }
 
+export interface ImageVariant {
+  width: number
+  height: number
+  url: string
+}
+
 export interface LoginCredentials {
   email: string
   password: string
@@ -8,3 +8,30 @@ require (
 )
 
 require github.com/golang-jwt/jwt/v5 v5.2.1
+
+require github.com/lib/pq v1.12.3
+
+require (
+	github.com/chai2010/webp v1.4.0
+	github.com/microcosm-cc/bluemonday v1.0.27
+	github.com/minio/minio-go/v7 v7.0.82
+	github.com/yuin/goldmark v1.8.6
+	golang.org/x/image v0.24.0
+	golang.org/x/net v0.30.0
+	golang.org/x/text v0.22.0
+)
+
+require (
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/go-ini/ini v1.67.0 // indirect
+	github.com/goccy/go-json v0.10.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/klauspost/cpuid/v2 v2.2.8 // indirect
+	github.com/minio/md5-simd v1.1.2 // indirect
+	github.com/rs/xid v1.6.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
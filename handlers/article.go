@@ -1,23 +1,47 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"test-ai-api/stores"
 	"test-ai-api/types"
 	"test-ai-api/utils"
+	"time"
 )
 
 type ArticleHandler struct {
-	store       *stores.ArticleStore
-	authorStore *stores.AuthorStore
+	store       stores.ArticleRepository
+	authorStore stores.AuthorRepository
 }
 
-func NewArticleHandler(store *stores.ArticleStore, authorStore *stores.AuthorStore) *ArticleHandler {
+func NewArticleHandler(store stores.ArticleRepository, authorStore stores.AuthorRepository) *ArticleHandler {
 	return &ArticleHandler{store: store, authorStore: authorStore}
 }
 
+func (h *ArticleHandler) viewer(r *http.Request) types.Viewer {
+	return requestViewer(r, h.authorStore)
+}
+
+// requestViewer describes the caller of a public route. Anonymous requests
+// and users without an author profile only get the published view.
+func requestViewer(r *http.Request, authorStore stores.AuthorRepository) types.Viewer {
+	userID, ok := r.Context().Value("userID").(int64)
+	if !ok {
+		return types.Viewer{}
+	}
+
+	viewer := types.Viewer{UserID: userID}
+	viewer.IsAdmin, _ = r.Context().Value("isAdmin").(bool)
+	if author, err := authorStore.GetByUserID(userID); err == nil {
+		viewer.AuthorID = author.ID
+	}
+	return viewer
+}
+
 func (h *ArticleHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var article types.ArticleCreate
 	if err := json.NewDecoder(r.Body).Decode(&article); err != nil {
@@ -37,26 +61,34 @@ func (h *ArticleHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.store.Create(article, author.ID)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithArticleError(w, err)
 		return
 	}
 
+	setETag(w, result.Version)
 	utils.RespondWithJSON(w, http.StatusCreated, result)
 }
 
 func (h *ArticleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	articles, err := h.store.GetAll(10, 0) // TODO: Add pagination
+	filter, err := parseArticleFilter(r)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	filter.Viewer = h.viewer(r)
 
-	if len(articles) == 0 {
-		utils.RespondWithJSON(w, http.StatusOK, []types.Article{})
+	page, err := h.store.GetAll(filter)
+	if err == stores.ErrInvalidCursor {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, articles)
+	page.Links = pageLinks(r, page)
+	utils.RespondWithJSON(w, http.StatusOK, page)
 }
 
 func (h *ArticleHandler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -67,12 +99,36 @@ func (h *ArticleHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	article, err := h.store.GetByID(id)
+	article, err := h.store.GetVisible(id, h.viewer(r))
 	if err != nil {
 		utils.RespondWithError(w, http.StatusNotFound, "Article not found")
 		return
 	}
 
+	setETag(w, article.Version)
+	utils.RespondWithJSON(w, http.StatusOK, article)
+}
+
+func (h *ArticleHandler) GetBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	article, err := h.store.GetBySlug(slug, h.viewer(r))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Article not found")
+		return
+	}
+
+	// The slug was renamed: point the client at the canonical one.
+	if article.Slug != slug {
+		location := "/api/articles/by-slug/" + article.Slug
+		w.Header().Set("Location", location)
+		utils.RespondWithJSON(w, http.StatusMovedPermanently, map[string]string{
+			"slug":     article.Slug,
+			"location": location,
+		})
+		return
+	}
+
+	setETag(w, article.Version)
 	utils.RespondWithJSON(w, http.StatusOK, article)
 }
 
@@ -84,6 +140,11 @@ func (h *ArticleHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var article types.ArticleUpdate
 	if err := json.NewDecoder(r.Body).Decode(&article); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -106,15 +167,113 @@ func (h *ArticleHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updated, err := h.store.Update(id, article)
+	updated, err := h.store.Update(id, version, article, userID)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithArticleError(w, err)
+		return
+	}
+
+	setETag(w, updated.Version)
+	utils.RespondWithJSON(w, http.StatusOK, updated)
+}
+
+// articleDocument is the part of an article a PATCH can change.
+type articleDocument struct {
+	Title            string     `json:"title"`
+	Slug             string     `json:"slug"`
+	ShortDescription string     `json:"short_description"`
+	Content          string     `json:"content"`
+	Status           string     `json:"status"`
+	PublishedAt      *time.Time `json:"published_at"`
+	Tags             []string   `json:"tags"`
+	Categories       []string   `json:"categories"`
+}
+
+// Patch applies a JSON Merge Patch or JSON Patch to the article and writes
+// only the fields it changed.
+func (h *ArticleHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.editableArticle(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	before := articleDocument{
+		Title:            existing.Title,
+		Slug:             existing.Slug,
+		ShortDescription: existing.ShortDescription,
+		Content:          existing.Content,
+		Status:           existing.Status,
+		PublishedAt:      existing.PublishedAt,
+		Tags:             []string{},
+		Categories:       []string{},
+	}
+	for _, tag := range existing.Tags {
+		before.Tags = append(before.Tags, tag.Slug)
+	}
+	for _, category := range existing.Categories {
+		before.Categories = append(before.Categories, category.Slug)
+	}
+	after := before
+	err := patchDocument(r, &after)
+	if err == nil {
+		err = validateArticleDocument(after)
+	}
+	if err != nil {
+		if !respondWithPatchError(w, err) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	patch := types.ArticlePatch{
+		Title:            changed(before.Title, after.Title),
+		Slug:             changed(before.Slug, after.Slug),
+		ShortDescription: changed(before.ShortDescription, after.ShortDescription),
+		Content:          changed(before.Content, after.Content),
+		Status:           changed(before.Status, after.Status),
+	}
+	if after.PublishedAt != nil && (before.PublishedAt == nil || !after.PublishedAt.Equal(*before.PublishedAt)) {
+		patch.PublishedAt = after.PublishedAt
+	}
+	if !slices.Equal(before.Tags, after.Tags) {
+		patch.Tags = append([]string{}, after.Tags...)
+	}
+	if !slices.Equal(before.Categories, after.Categories) {
+		patch.Categories = append([]string{}, after.Categories...)
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	updated, err := h.store.Patch(existing.ID, version, patch, userID)
+	if err != nil {
+		respondWithArticleError(w, err)
 		return
 	}
 
+	setETag(w, updated.Version)
 	utils.RespondWithJSON(w, http.StatusOK, updated)
 }
 
+func validateArticleDocument(doc articleDocument) error {
+	if err := requireText("title", doc.Title); err != nil {
+		return err
+	}
+	if err := requireText("slug", doc.Slug); err != nil {
+		return err
+	}
+	if err := requireText("content", doc.Content); err != nil {
+		return err
+	}
+	if !types.IsArticleStatus(doc.Status) {
+		return &invalidDocumentError{reason: "unknown article status " + strconv.Quote(doc.Status)}
+	}
+	return nil
+}
+
 func (h *ArticleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -146,3 +305,103 @@ func (h *ArticleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Article deleted successfully"})
 }
+
+func (h *ArticleHandler) GetScheduled(w http.ResponseWriter, r *http.Request) {
+	viewer := h.viewer(r)
+	if !viewer.IsAdmin && viewer.AuthorID == 0 {
+		utils.RespondWithError(w, http.StatusForbidden, "Only authors can view scheduled articles")
+		return
+	}
+
+	articles, err := h.store.GetScheduled(viewer)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, articles)
+}
+
+func (h *ArticleHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, types.ArticleStatusInReview)
+}
+
+// Publish makes the article live now, or schedules it when the body carries
+// a published_at in the future.
+func (h *ArticleHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, types.ArticleStatusPublished)
+}
+
+func (h *ArticleHandler) Unpublish(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, types.ArticleStatusDraft)
+}
+
+func (h *ArticleHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, types.ArticleStatusArchived)
+}
+
+func (h *ArticleHandler) transition(w http.ResponseWriter, r *http.Request, to string) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid article ID")
+		return
+	}
+
+	var body types.ArticleTransition
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+	}
+
+	existingArticle, err := h.store.GetByID(id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Article not found")
+		return
+	}
+
+	viewer := h.viewer(r)
+	if !viewer.IsAdmin && viewer.AuthorID != existingArticle.AuthorID {
+		utils.RespondWithError(w, http.StatusForbidden, "Not authorized to change this article")
+		return
+	}
+
+	if to == types.ArticleStatusPublished && body.PublishedAt != nil && body.PublishedAt.After(time.Now()) {
+		to = types.ArticleStatusScheduled
+	}
+
+	updated, err := h.store.Transition(id, to, body.PublishedAt, viewer.UserID)
+	if err != nil {
+		respondWithArticleError(w, err)
+		return
+	}
+
+	setETag(w, updated.Version)
+	utils.RespondWithJSON(w, http.StatusOK, updated)
+}
+
+func respondWithArticleError(w http.ResponseWriter, err error) {
+	var transitionErr *stores.TransitionError
+	var conflictErr *stores.VersionConflictError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		utils.RespondWithError(w, http.StatusNotFound, "Article not found")
+	case errors.As(err, &conflictErr):
+		respondWithVersionConflict(w, conflictErr)
+	case errors.As(err, &transitionErr):
+		utils.RespondWithJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":   transitionErr.Error(),
+			"status":  transitionErr.From,
+			"allowed": types.ArticleTransitions(transitionErr.From),
+		})
+	case errors.Is(err, stores.ErrSlugTaken):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, stores.ErrScheduleInPast), errors.Is(err, stores.ErrInvalidSlug),
+		errors.Is(err, stores.ErrUnknownTag), errors.Is(err, stores.ErrUnknownCategory):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -2,18 +2,20 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"test-ai-api/stores"
 	"test-ai-api/types"
 	"test-ai-api/utils"
 )
 
 type AuthHandler struct {
-	userStore *stores.UserStore
+	userStore stores.UserRepository
 }
 
-func NewAuthHandler(userStore *stores.UserStore) *AuthHandler {
+func NewAuthHandler(userStore stores.UserRepository) *AuthHandler {
 	return &AuthHandler{userStore: userStore}
 }
 
@@ -41,7 +43,6 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user, err := h.userStore.Login(loginRequest.Email, loginRequest.Password)
-	fmt.Println(user)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
@@ -120,3 +121,72 @@ func (h *AuthHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	}
 	utils.RespondWithJSON(w, http.StatusOK, user)
 }
+
+// userDocument is the part of a user's profile a PATCH can change.
+type userDocument struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+}
+
+// PatchUser applies a JSON Merge Patch or JSON Patch to a user's profile.
+// Users may change their own profile; admins may change anyone's.
+func (h *AuthHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	isAdmin, _ := r.Context().Value("isAdmin").(bool)
+	if !isAdmin && id != userID {
+		utils.RespondWithError(w, http.StatusForbidden, "Not authorized to update this user")
+		return
+	}
+
+	existing, err := h.userStore.GetByID(id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	before := userDocument{
+		FirstName: existing.FirstName,
+		LastName:  existing.LastName,
+		Email:     existing.Email,
+	}
+	after := before
+	err = patchDocument(r, &after)
+	if err == nil {
+		err = requireText("first_name", after.FirstName)
+	}
+	if err == nil {
+		err = requireText("last_name", after.LastName)
+	}
+	if err == nil && !strings.Contains(after.Email, "@") {
+		err = &invalidDocumentError{reason: "email must be a valid address"}
+	}
+	if err != nil {
+		if !respondWithPatchError(w, err) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	updated, err := h.userStore.Patch(id, types.UserPatch{
+		FirstName: changed(before.FirstName, after.FirstName),
+		LastName:  changed(before.LastName, after.LastName),
+		Email:     changed(before.Email, after.Email),
+	})
+	if errors.Is(err, stores.ErrEmailTaken) {
+		utils.RespondWithError(w, http.StatusConflict, "Email already exists")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, updated)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"test-ai-api/stores"
@@ -10,10 +11,10 @@ import (
 )
 
 type AuthorHandler struct {
-	store *stores.AuthorStore
+	store stores.AuthorRepository
 }
 
-func NewAuthorHandler(store *stores.AuthorStore) *AuthorHandler {
+func NewAuthorHandler(store stores.AuthorRepository) *AuthorHandler {
 	return &AuthorHandler{store: store}
 }
 
@@ -31,6 +32,7 @@ func (h *AuthorHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, result.Version)
 	utils.RespondWithJSON(w, http.StatusCreated, result)
 }
 
@@ -57,10 +59,16 @@ func (h *AuthorHandler) GetBySlug(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, author.Version)
 	utils.RespondWithJSON(w, http.StatusOK, author)
 }
 
 func (h *AuthorHandler) Update(w http.ResponseWriter, r *http.Request) {
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var author types.AuthorUpdate
 	if err := json.NewDecoder(r.Body).Decode(&author); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -74,12 +82,85 @@ func (h *AuthorHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updated, err := h.store.Update(result.ID, author)
+	updated, err := h.store.Update(result.ID, version, author)
+	var conflictErr *stores.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		respondWithVersionConflict(w, conflictErr)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	setETag(w, updated.Version)
+	utils.RespondWithJSON(w, http.StatusOK, updated)
+}
+
+// authorDocument is the part of an author a PATCH can change.
+type authorDocument struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Bio       string `json:"bio"`
+}
+
+// Patch applies a JSON Merge Patch or JSON Patch to the author and writes
+// only the fields it changed.
+func (h *AuthorHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	existing, err := h.store.GetBySlug(r.PathValue("slug"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Author not found")
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	isAdmin, _ := r.Context().Value("isAdmin").(bool)
+	if !isAdmin && existing.UserID != userID {
+		utils.RespondWithError(w, http.StatusForbidden, "Not authorized to update this author")
+		return
+	}
+
+	before := authorDocument{
+		FirstName: existing.FirstName,
+		LastName:  existing.LastName,
+		Bio:       existing.Bio,
+	}
+	after := before
+	err = patchDocument(r, &after)
+	if err == nil {
+		err = requireText("first_name", after.FirstName)
+	}
+	if err == nil {
+		err = requireText("last_name", after.LastName)
+	}
+	if err != nil {
+		if !respondWithPatchError(w, err) {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	updated, err := h.store.Patch(existing.ID, version, types.AuthorPatch{
+		FirstName: changed(before.FirstName, after.FirstName),
+		LastName:  changed(before.LastName, after.LastName),
+		Bio:       changed(before.Bio, after.Bio),
+	})
+	var conflictErr *stores.VersionConflictError
+	if errors.As(err, &conflictErr) {
+		respondWithVersionConflict(w, conflictErr)
+		return
+	}
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	setETag(w, updated.Version)
 	utils.RespondWithJSON(w, http.StatusOK, updated)
 }
 
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"test-ai-api/stores"
+	"test-ai-api/types"
+	"test-ai-api/utils"
+)
+
+type CategoryHandler struct {
+	*termHandler[types.Category, types.CategoryCreate, types.CategoryUpdate]
+}
+
+func NewCategoryHandler(store stores.CategoryRepository) *CategoryHandler {
+	return &CategoryHandler{
+		termHandler: &termHandler[types.Category, types.CategoryCreate, types.CategoryUpdate]{
+			store:      store,
+			noun:       "Category",
+			plural:     "categories",
+			id:         func(c types.Category) int64 { return c.ID },
+			createName: func(c types.CategoryCreate) string { return c.Name },
+			updateName: func(c types.CategoryUpdate) string { return c.Name },
+		},
+	}
+}
+
+// Categories shape the site's navigation, so only admins manage them.
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if isAdmin, _ := r.Context().Value("isAdmin").(bool); !isAdmin {
+		utils.RespondWithError(w, http.StatusForbidden, "Only admins can create categories")
+		return
+	}
+
+	h.create(w, r)
+}
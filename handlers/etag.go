@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"test-ai-api/stores"
+	"test-ai-api/utils"
+)
+
+var (
+	errMissingIfMatch = errors.New("If-Match header is required")
+	errInvalidIfMatch = errors.New("If-Match must be an ETag from a previous response")
+)
+
+// etag renders a row version as a strong entity tag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// hashETag is a strong entity tag for data that identifies a
+// representation, such as its bytes or an immutable storage key.
+func hashETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ifMatchVersion reads the row version a write was based on from the
+// If-Match header. Only a single ETag is accepted: a wildcard or a list
+// would let the write go through without saying which version it saw.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errMissingIfMatch
+	}
+	header = strings.TrimPrefix(header, "W/")
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// requireIfMatch is ifMatchVersion for handlers: it writes 428 or 400 itself
+// and reports whether the request may go on.
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	version, err := ifMatchVersion(r)
+	switch {
+	case errors.Is(err, errMissingIfMatch):
+		utils.RespondWithError(w, http.StatusPreconditionRequired, err.Error())
+		return 0, false
+	case err != nil:
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+	return version, true
+}
+
+// respondWithVersionConflict reports a failed If-Match along with the
+// version the row is at now, so the client can refetch and retry.
+func respondWithVersionConflict(w http.ResponseWriter, err *stores.VersionConflictError) {
+	setETag(w, err.Current)
+	utils.RespondWithJSON(w, http.StatusPreconditionFailed, map[string]interface{}{
+		"error":   err.Error(),
+		"version": err.Current,
+	})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"path"
+	"test-ai-api/feed"
+	"test-ai-api/site"
+)
+
+// FeedHandler serves the RSS, Atom and JSON feeds of the home page, each
+// author and each tag. The format comes from the file name the route ends
+// in, see site.FeedFiles.
+type FeedHandler struct {
+	site *site.Site
+}
+
+func NewFeedHandler(s *site.Site) *FeedHandler {
+	return &FeedHandler{site: s}
+}
+
+func (h *FeedHandler) Home(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "/", func(origin string, full bool) (feed.Feed, error) {
+		return h.site.HomeFeed(origin, full)
+	})
+}
+
+func (h *FeedHandler) Author(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	h.serve(w, r, site.AuthorPath(slug), func(origin string, full bool) (feed.Feed, error) {
+		return h.site.AuthorFeed(origin, slug, full)
+	})
+}
+
+func (h *FeedHandler) Tag(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	h.serve(w, r, site.TagPath(slug), func(origin string, full bool) (feed.Feed, error) {
+		return h.site.TagFeed(origin, slug, full)
+	})
+}
+
+// serve builds and encodes a feed, then lets http.ServeContent answer
+// conditional requests. The ETag is a hash of the encoded feed and
+// Last-Modified is the newest item's update time, so readers polling with
+// If-None-Match or If-Modified-Since get a 304 until something changes.
+func (h *FeedHandler) serve(w http.ResponseWriter, r *http.Request, listing string, build func(origin string, full bool) (feed.Feed, error)) {
+	file := path.Base(r.URL.Path)
+	format, ok := site.FeedFiles[file]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	full := h.site.Config().FeedFullContent
+	switch r.URL.Query().Get("content") {
+	case "":
+	case "full":
+		full = true
+	case "summary":
+		full = false
+	default:
+		http.Error(w, "content must be full or summary", http.StatusBadRequest)
+		return
+	}
+
+	origin := requestOrigin(r)
+	f, err := build(origin, full)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("feed %s: %v", r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	self := h.site.FeedURL(origin, listing, file)
+	if r.URL.RawQuery != "" {
+		self += "?" + r.URL.RawQuery
+	}
+	body, err := feed.Encode(format, f, self)
+	if err != nil {
+		log.Printf("feed %s: %v", r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", hashETag(body))
+	w.Header().Set("Content-Type", format.ContentType())
+	http.ServeContent(w, r, file, f.Updated, bytes.NewReader(body))
+}
@@ -2,22 +2,65 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"os"
+	"path"
+	"slices"
 	"strconv"
+	"strings"
+	"test-ai-api/imaging"
 	"test-ai-api/stores"
 	"test-ai-api/types"
 	"test-ai-api/utils"
 )
 
 type ImageHandler struct {
-	store *stores.ImageStore
+	store  stores.ImageRepository
+	config imaging.Config
 }
 
-func NewImageHandler(store *stores.ImageStore) *ImageHandler {
-	return &ImageHandler{store: store}
+func NewImageHandler(store stores.ImageRepository, config imaging.Config) *ImageHandler {
+	return &ImageHandler{store: store, config: config}
 }
 
+// withVariants lists the resized copies an upload is offered in. They are
+// only generated when first requested, see MediaHandler.ServeVariant.
+func (h *ImageHandler) withVariants(image types.Image) types.Image {
+	if image.StorageKey == "" || image.Width == 0 {
+		return image
+	}
+
+	var srcset []string
+	for _, width := range h.config.Widths {
+		if !h.config.Offers(width, image.Width) {
+			continue
+		}
+		variant := types.ImageVariant{
+			Width:  width,
+			Height: imaging.VariantHeight(width, image.Width, image.Height),
+			URL:    stores.VariantPath(image, width),
+		}
+		image.Variants = append(image.Variants, variant)
+		srcset = append(srcset, fmt.Sprintf("%s %dw", variant.URL, variant.Width))
+	}
+	srcset = append(srcset, fmt.Sprintf("%s %dw", image.URL, image.Width))
+	image.SrcSet = strings.Join(srcset, ", ")
+	return image
+}
+
+// Create handles POST /api/images. A multipart/form-data request uploads
+// the image in its "file" field; a JSON body records a link to an image
+// hosted elsewhere.
 func (h *ImageHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		h.upload(w, r)
+		return
+	}
+
 	var image types.ImageCreate
 	if err := json.NewDecoder(r.Body).Decode(&image); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -33,6 +76,149 @@ func (h *ImageHandler) Create(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusCreated, result)
 }
 
+// upload checks what was really uploaded, whatever it is called, strips
+// its metadata and hands it to the store. The file is streamed through
+// temporary files rather than held in memory: one for the upload as sent,
+// which is sniffed and stripped, and one for the stripped copy that is
+// hashed and stored.
+func (h *ImageHandler) upload(w http.ResponseWriter, r *http.Request) {
+	// Allow a little over the file limit for the multipart framing.
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.MaxBytes+64<<10)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid multipart body")
+		return
+	}
+
+	var part io.Reader
+	var filename string
+	for part == nil {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			utils.RespondWithError(w, http.StatusBadRequest, "Missing file field")
+			return
+		}
+		if err != nil {
+			h.respondWithUploadError(w, err)
+			return
+		}
+		if p.FormName() == "file" {
+			part, filename = p, path.Base(strings.ReplaceAll(p.FileName(), `\`, "/"))
+		}
+	}
+
+	original, err := spool(part, h.config.MaxBytes)
+	if err != nil {
+		h.respondWithUploadError(w, err)
+		return
+	}
+	defer removeTemp(original)
+
+	info, err := h.config.Inspect(original)
+	if err != nil {
+		h.respondWithUploadError(w, err)
+		return
+	}
+
+	stripped, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		h.respondWithUploadError(w, err)
+		return
+	}
+	defer removeTemp(stripped)
+
+	if _, err = original.Seek(0, io.SeekStart); err == nil {
+		err = imaging.StripMetadata(info.Format, stripped, original)
+	}
+	var size int64
+	if err == nil {
+		size, err = stripped.Seek(0, io.SeekCurrent)
+	}
+	if err == nil {
+		_, err = stripped.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		h.respondWithUploadError(w, err)
+		return
+	}
+
+	result, err := h.store.CreateUpload(r.Context(), types.ImageUpload{
+		Filename: filename,
+		Size:     size,
+		MimeType: imaging.Formats[info.Format].MimeType,
+		Width:    info.Width,
+		Height:   info.Height,
+	}, stripped)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, h.withVariants(result))
+}
+
+// spool copies an upload to a temporary file, failing with
+// *http.MaxBytesError once it passes limit bytes.
+func spool(r io.Reader, limit int64) (*os.File, error) {
+	f, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, limit+1))
+	if err == nil && n > limit {
+		err = &http.MaxBytesError{Limit: limit}
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		removeTemp(f)
+		return nil, err
+	}
+	return f, nil
+}
+
+func removeTemp(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// respondWithUploadError explains a rejected upload. The code field is
+// stable for clients to switch on, and the limits say what would have been
+// accepted.
+func (h *ImageHandler) respondWithUploadError(w http.ResponseWriter, err error) {
+	var tooBig *http.MaxBytesError
+	var sizeErr *imaging.SizeError
+	switch {
+	case errors.As(err, &tooBig):
+		utils.RespondWithJSON(w, http.StatusRequestEntityTooLarge, map[string]interface{}{
+			"error":     fmt.Sprintf("Upload exceeds %d bytes", h.config.MaxBytes),
+			"code":      "file_too_large",
+			"max_bytes": h.config.MaxBytes,
+		})
+	case errors.As(err, &sizeErr):
+		utils.RespondWithJSON(w, http.StatusRequestEntityTooLarge, map[string]interface{}{
+			"error":         fmt.Sprintf("Image is %dx%d pixels, larger than allowed", sizeErr.Width, sizeErr.Height),
+			"code":          "image_too_large",
+			"max_dimension": h.config.MaxDimension,
+			"max_pixels":    h.config.MaxPixels,
+		})
+	case errors.Is(err, imaging.ErrUnsupported):
+		var allowed []string
+		for _, kind := range imaging.Formats {
+			allowed = append(allowed, kind.MimeType)
+		}
+		slices.Sort(allowed)
+		utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, map[string]interface{}{
+			"error":   "Unsupported image, expected PNG, JPEG, GIF or WebP",
+			"code":    "unsupported_media_type",
+			"allowed": allowed,
+		})
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
 func (h *ImageHandler) GetById(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -46,7 +232,7 @@ func (h *ImageHandler) GetById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, image)
+	utils.RespondWithJSON(w, http.StatusOK, h.withVariants(image))
 }
 
 func (h *ImageHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -57,7 +243,7 @@ func (h *ImageHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.Delete(id); err != nil {
+	if err := h.store.Delete(r.Context(), id); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
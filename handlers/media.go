@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"test-ai-api/imaging"
+	"test-ai-api/storage"
+	"test-ai-api/stores"
+	"test-ai-api/types"
+)
+
+// MediaHandler serves the bytes of uploaded images.
+type MediaHandler struct {
+	store  stores.ImageRepository
+	blobs  storage.BlobStorage
+	config imaging.Config
+}
+
+func NewMediaHandler(store stores.ImageRepository, blobs storage.BlobStorage, config imaging.Config) *MediaHandler {
+	return &MediaHandler{store: store, blobs: blobs, config: config}
+}
+
+// upload looks up the uploaded image with the id in the request path. It
+// writes the error response itself and returns false when there is none.
+func (h *MediaHandler) upload(w http.ResponseWriter, r *http.Request) (types.Image, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return types.Image{}, false
+	}
+
+	image, err := h.store.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && image.StorageKey == "") {
+		http.NotFound(w, r)
+		return types.Image{}, false
+	}
+	if err != nil {
+		log.Printf("media %d: %v", id, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return types.Image{}, false
+	}
+	return image, true
+}
+
+// Serve handles GET /media/{id}/{filename}. The blob is streamed from
+// storage with http.ServeContent, which answers Range, If-Range and
+// If-None-Match requests by seeking instead of reading the whole file.
+//
+// An upload's bytes never change: every upload gets a fresh storage key
+// and nothing rewrites one. That makes the key a strong validator, and
+// lets clients and proxies cache the response forever.
+func (h *MediaHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	image, ok := h.upload(w, r)
+	if !ok {
+		return
+	}
+
+	// Old or hand-typed file names still work, but point at one URL.
+	if r.PathValue("filename") != path.Base(image.URL) {
+		http.Redirect(w, r, image.URL, http.StatusMovedPermanently)
+		return
+	}
+
+	blob, err := h.blobs.Open(r.Context(), image.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf("media %d: blob %s is missing", image.ID, image.StorageKey)
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("media %d: %v", image.ID, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	serveBlob(w, r, image, image.StorageKey, image.MimeType, blob)
+}
+
+// ServeVariant handles GET /media/{id}/{width}/{filename}. Derivatives are
+// made the first time they are asked for and kept in blob storage next to
+// the original, so later requests are served like any other upload.
+func (h *MediaHandler) ServeVariant(w http.ResponseWriter, r *http.Request) {
+	image, ok := h.upload(w, r)
+	if !ok {
+		return
+	}
+
+	width, err := strconv.Atoi(r.PathValue("width"))
+	if err != nil || !h.config.Offers(width, image.Width) {
+		http.NotFound(w, r)
+		return
+	}
+
+	variantURL := stores.VariantPath(image, width)
+	if r.PathValue("filename") != path.Base(variantURL) {
+		http.Redirect(w, r, variantURL, http.StatusMovedPermanently)
+		return
+	}
+
+	key := stores.VariantKey(image, width)
+	_, variantType := imaging.VariantFormat(image.MimeType)
+	blob, err := h.blobs.Open(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		err = h.resize(r, image, width, key, variantType)
+		if err == nil {
+			blob, err = h.blobs.Open(r.Context(), key)
+		}
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf("media %d: blob %s is missing", image.ID, image.StorageKey)
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("media %d at %dpx: %v", image.ID, width, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	serveBlob(w, r, image, key, variantType, blob)
+}
+
+// resize generates the width pixels wide derivative of image and stores it
+// under key. Two requests racing here both do the work; the puts are
+// atomic, so either result is fine.
+func (h *MediaHandler) resize(r *http.Request, image types.Image, width int, key, variantType string) error {
+	original, err := h.blobs.Open(r.Context(), image.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	var buf bytes.Buffer
+	if err := h.config.Resize(&buf, original, width, image.Width, image.Height, variantType); err != nil {
+		return err
+	}
+	return h.blobs.Put(r.Context(), key, &buf, int64(buf.Len()), variantType)
+}
+
+// serveBlob writes a stored file. Keys are never rewritten, which makes the
+// key a strong validator and lets clients and proxies cache the response
+// forever.
+func serveBlob(w http.ResponseWriter, r *http.Request, image types.Image, key, mimeType string, blob *storage.Blob) {
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("ETag", hashETag([]byte(key)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", image.CreatedAt, blob)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"test-ai-api/site"
+)
+
+// PageHandler serves the server-rendered blog pages.
+type PageHandler struct {
+	site *site.Site
+}
+
+func NewPageHandler(s *site.Site) *PageHandler {
+	return &PageHandler{site: s}
+}
+
+func (h *PageHandler) Home(w http.ResponseWriter, r *http.Request) {
+	n, ok := h.pageNumber(w, r, "/")
+	if !ok {
+		return
+	}
+	page, err := h.site.Home(n)
+	h.render(w, r, page, err)
+}
+
+func (h *PageHandler) Article(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	page, err := h.site.Article(slug)
+	if err == nil && page.Article.Slug != slug {
+		http.Redirect(w, r, site.ArticlePath(page.Article.Slug), http.StatusMovedPermanently)
+		return
+	}
+	h.render(w, r, page, err)
+}
+
+func (h *PageHandler) Author(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	n, ok := h.pageNumber(w, r, site.AuthorPath(slug))
+	if !ok {
+		return
+	}
+	page, err := h.site.Author(slug, n)
+	h.render(w, r, page, err)
+}
+
+func (h *PageHandler) Tag(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	n, ok := h.pageNumber(w, r, site.TagPath(slug))
+	if !ok {
+		return
+	}
+	page, err := h.site.Tag(slug, n)
+	h.render(w, r, page, err)
+}
+
+// Sitemap serves the sitemap and, on big sites, the parts its index
+// lists. Without SITE_URL there is none.
+func (h *PageHandler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	data, updated, err := h.site.Sitemap(r.URL.Path)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("sitemap %s: %v", r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	http.ServeContent(w, r, "", updated, bytes.NewReader(data))
+}
+
+func (h *PageHandler) Robots(w http.ResponseWriter, r *http.Request) {
+	data, err := h.site.Robots()
+	if err != nil {
+		log.Printf("robots.txt: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(data)
+}
+
+// Static serves the theme's static files under /static/.
+func (h *PageHandler) Static() http.Handler {
+	return http.StripPrefix("/static/", http.FileServerFS(h.site.Theme().Static))
+}
+
+func (h *PageHandler) notFound(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, site.Page{}, sql.ErrNoRows)
+}
+
+// pageNumber reads the {n} of a /page/{n} route. Page 1 has no such URL of
+// its own, so requests for it are redirected to first, and anything that is
+// not a page number is not found.
+func (h *PageHandler) pageNumber(w http.ResponseWriter, r *http.Request, first string) (int, bool) {
+	v := r.PathValue("n")
+	if v == "" {
+		return 1, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || strconv.Itoa(n) != v {
+		h.notFound(w, r)
+		return 0, false
+	}
+	if n == 1 {
+		http.Redirect(w, r, first, http.StatusMovedPermanently)
+		return 0, false
+	}
+	return n, true
+}
+
+// render writes page, or the not found page when err is sql.ErrNoRows. The
+// page is rendered into a buffer first so a template error can still turn
+// into a clean 500.
+func (h *PageHandler) render(w http.ResponseWriter, r *http.Request, page site.Page, err error) {
+	status := http.StatusOK
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusNotFound
+		page, err = h.site.NotFound(), nil
+	}
+	if err != nil {
+		log.Printf("page %s: %v", r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.site.Render(&buf, requestOrigin(r), page); err != nil {
+		log.Printf("page %s: %v", r.URL.Path, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// requestOrigin is the scheme and host the request was made to, honouring
+// X-Forwarded-Proto from a TLS-terminating proxy.
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
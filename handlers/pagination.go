@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"test-ai-api/types"
+	"time"
+)
+
+const (
+	defaultPerPage = 10
+	maxPerPage     = 100
+)
+
+func parseArticleFilter(r *http.Request) (types.ArticleFilter, error) {
+	q := r.URL.Query()
+	filter := types.ArticleFilter{
+		Status:     q.Get("status"),
+		AuthorSlug: q.Get("author"),
+		Tag:        q.Get("tag"),
+		Category:   q.Get("category"),
+		Sort:       q.Get("sort"),
+		Cursor:     q.Get("cursor"),
+		Page:       1,
+		PerPage:    defaultPerPage,
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = types.ArticleSortNewest
+	case types.ArticleSortNewest, types.ArticleSortOldest, types.ArticleSortTitle:
+	default:
+		return filter, errors.New("Invalid sort, expected newest, oldest or title")
+	}
+
+	var err error
+	if filter.Page, filter.PerPage, err = parsePage(q); err != nil {
+		return filter, err
+	}
+	if filter.PublishedFrom, err = parseDateParam(q, "published_from"); err != nil {
+		return filter, err
+	}
+	if filter.PublishedTo, err = parseDateParam(q, "published_to"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parsePage reads the page and per_page query parameters.
+func parsePage(q url.Values) (page, perPage int, err error) {
+	page, perPage = 1, defaultPerPage
+	if v := q.Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			return 0, 0, errors.New("Invalid page")
+		}
+	}
+	if v := q.Get("per_page"); v != "" {
+		if perPage, err = strconv.Atoi(v); err != nil || perPage < 1 || perPage > maxPerPage {
+			return 0, 0, fmt.Errorf("Invalid per_page, expected 1 to %d", maxPerPage)
+		}
+	}
+	return page, perPage, nil
+}
+
+// parseDateParam accepts either a full RFC 3339 timestamp or a plain date.
+func parseDateParam(q url.Values, name string) (*time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s, expected RFC 3339 or YYYY-MM-DD", name)
+	}
+	return &t, nil
+}
+
+// pageLinks builds next/prev URLs that keep the caller's other query
+// parameters intact.
+func pageLinks[T any](r *http.Request, page types.Page[T]) types.PageLinks {
+	var links types.PageLinks
+
+	link := func(set func(url.Values)) string {
+		q := r.URL.Query()
+		set(q)
+		u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+		return u.String()
+	}
+
+	// Offset pages that don't hand out cursors still know from the total
+	// whether there is more.
+	hasNext := page.NextCursor != "" || (page.Page > 0 && page.Page*page.PerPage < page.Total)
+	if hasNext {
+		if page.Page == 0 {
+			links.Next = link(func(q url.Values) {
+				q.Set("cursor", page.NextCursor)
+			})
+		} else {
+			links.Next = link(func(q url.Values) {
+				q.Set("page", strconv.Itoa(page.Page+1))
+			})
+		}
+	}
+	if page.PrevCursor != "" {
+		links.Prev = link(func(q url.Values) {
+			q.Set("cursor", page.PrevCursor)
+		})
+	} else if page.Page > 1 {
+		links.Prev = link(func(q url.Values) {
+			q.Set("page", strconv.Itoa(page.Page-1))
+		})
+	}
+
+	return links
+}
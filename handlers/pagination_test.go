@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"test-ai-api/types"
+	"testing"
+)
+
+func TestPageLinks(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		page       types.Page[int]
+		next, prev string
+	}{
+		{
+			name:   "offset page in the middle",
+			target: "/api/articles?page=2&tag=go",
+			page:   types.Page[int]{Page: 2, PerPage: 10, Total: 25},
+			next:   "/api/articles?page=3&tag=go",
+			prev:   "/api/articles?page=1&tag=go",
+		},
+		{
+			name:   "last offset page",
+			target: "/api/articles?page=3",
+			page:   types.Page[int]{Page: 3, PerPage: 10, Total: 25},
+			prev:   "/api/articles?page=2",
+		},
+		{
+			name:   "cursor page with both neighbours",
+			target: "/api/articles?cursor=abc&per_page=5",
+			page:   types.Page[int]{PerPage: 5, NextCursor: "def", PrevCursor: "xyz"},
+			next:   "/api/articles?cursor=def&per_page=5",
+			prev:   "/api/articles?cursor=xyz&per_page=5",
+		},
+		{
+			name:   "first cursor page",
+			target: "/api/articles?cursor=abc",
+			page:   types.Page[int]{PerPage: 10, NextCursor: "def"},
+			next:   "/api/articles?cursor=def",
+		},
+	}
+	for _, tt := range tests {
+		links := pageLinks(httptest.NewRequest("GET", tt.target, nil), tt.page)
+		if links.Next != tt.next || links.Prev != tt.prev {
+			t.Errorf("%s: links = %+v, want next %q, prev %q", tt.name, links, tt.next, tt.prev)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"test-ai-api/patch"
+	"test-ai-api/utils"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// maxPatchBytes caps PATCH request bodies.
+const maxPatchBytes = 1 << 20
+
+var errUnsupportedPatch = fmt.Errorf("Content-Type must be %s or %s", mergePatchType, jsonPatchType)
+
+// invalidDocumentError means the patch applied cleanly but the result is not
+// something the resource can hold.
+type invalidDocumentError struct {
+	reason string
+}
+
+func (e *invalidDocumentError) Error() string {
+	return e.reason
+}
+
+// patchDocument applies the request body to doc as a JSON Merge Patch or a
+// JSON Patch, chosen by Content-Type, and decodes the result back into doc.
+// Plain application/json is treated as a merge patch. The patched document
+// may only contain doc's own fields.
+func patchDocument[T any](r *http.Request, doc *T) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return errUnsupportedPatch
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPatchBytes))
+	if err != nil {
+		return err
+	}
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch mediaType {
+	case mergePatchType, "application/json":
+		patched, err = patch.Merge(original, body)
+	case jsonPatchType:
+		patched, err = patch.Apply(original, body)
+	default:
+		return errUnsupportedPatch
+	}
+	if err != nil {
+		return err
+	}
+
+	var result T
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&result); err != nil {
+		return &invalidDocumentError{reason: "patched document is invalid: " + err.Error()}
+	}
+	*doc = result
+	return nil
+}
+
+// requireText rejects a patched field that ended up blank.
+func requireText(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return &invalidDocumentError{reason: field + " must not be empty"}
+	}
+	return nil
+}
+
+// changed returns &after when it differs from before, so a patch struct
+// only carries the fields that actually moved.
+func changed[T comparable](before, after T) *T {
+	if before == after {
+		return nil
+	}
+	return &after
+}
+
+// respondWithPatchError reports errors from patchDocument, and returns false
+// for anything else so the caller can handle it.
+func respondWithPatchError(w http.ResponseWriter, err error) bool {
+	var invalid *invalidDocumentError
+	switch {
+	case errors.Is(err, errUnsupportedPatch):
+		utils.RespondWithError(w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, patch.ErrInvalidPatch):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, patch.ErrTestFailed):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, patch.ErrPathNotFound), errors.As(err, &invalid):
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		return false
+	}
+	return true
+}
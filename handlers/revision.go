@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"test-ai-api/diff"
+	"test-ai-api/types"
+	"test-ai-api/utils"
+)
+
+// revisionDiff is the change to one field between two revisions. Unified is
+// set in unified mode, Edits in word mode.
+type revisionDiff struct {
+	Field   string      `json:"field"`
+	Changed bool        `json:"changed"`
+	Unified string      `json:"unified,omitempty"`
+	Edits   []diff.Edit `json:"edits,omitempty"`
+}
+
+// editableArticle parses the {id} path value and checks that the caller is
+// the article's author or an admin. It writes the error response itself and
+// returns ok=false when the request should stop.
+func (h *ArticleHandler) editableArticle(w http.ResponseWriter, r *http.Request) (types.Article, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid article ID")
+		return types.Article{}, false
+	}
+
+	article, err := h.store.GetByID(id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Article not found")
+		return types.Article{}, false
+	}
+
+	viewer := h.viewer(r)
+	if !viewer.IsAdmin && viewer.AuthorID != article.AuthorID {
+		utils.RespondWithError(w, http.StatusForbidden, "Not authorized to edit this article")
+		return types.Article{}, false
+	}
+	return article, true
+}
+
+func (h *ArticleHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.editableArticle(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.store.GetRevisions(article.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, revisions)
+}
+
+func (h *ArticleHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.editableArticle(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid revision")
+		return
+	}
+
+	revision, err := h.store.GetRevision(article.ID, rev)
+	if err != nil {
+		respondWithRevisionError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, revision)
+}
+
+// DiffRevisions compares revisions ?from= and ?to= field by field. mode is
+// "unified" (the default) for diff -u style text or "word" for a list of
+// word-level edits.
+func (h *ArticleHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.editableArticle(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid from revision")
+		return
+	}
+	to, err := strconv.Atoi(query.Get("to"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid to revision")
+		return
+	}
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = "unified"
+	}
+	if mode != "unified" && mode != "word" {
+		utils.RespondWithError(w, http.StatusBadRequest, "mode must be unified or word")
+		return
+	}
+
+	a, err := h.store.GetRevision(article.ID, from)
+	if err != nil {
+		respondWithRevisionError(w, err)
+		return
+	}
+	b, err := h.store.GetRevision(article.ID, to)
+	if err != nil {
+		respondWithRevisionError(w, err)
+		return
+	}
+
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"title", a.Title, b.Title},
+		{"short_description", a.ShortDescription, b.ShortDescription},
+		{"content", a.Content, b.Content},
+	}
+
+	diffs := make([]revisionDiff, 0, len(fields))
+	for _, f := range fields {
+		d := revisionDiff{Field: f.name, Changed: f.old != f.new}
+		if d.Changed {
+			if mode == "word" {
+				d.Edits = diff.Words(f.old, f.new)
+			} else {
+				d.Unified = diff.Unified(
+					fmt.Sprintf("%s@%d", f.name, from),
+					fmt.Sprintf("%s@%d", f.name, to),
+					f.old, f.new, 3,
+				)
+			}
+		}
+		diffs = append(diffs, d)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"mode":  mode,
+		"diffs": diffs,
+	})
+}
+
+// RestoreRevision copies an old revision's title, description and content
+// back onto the article as a new revision.
+func (h *ArticleHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	article, ok := h.editableArticle(w, r)
+	if !ok {
+		return
+	}
+
+	rev, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid revision")
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	restored, err := h.store.RestoreRevision(article.ID, version, rev, userID)
+	if err != nil {
+		respondWithRevisionError(w, err)
+		return
+	}
+
+	setETag(w, restored.Version)
+	utils.RespondWithJSON(w, http.StatusOK, restored)
+}
+
+func respondWithRevisionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondWithError(w, http.StatusNotFound, "Revision not found")
+		return
+	}
+	respondWithArticleError(w, err)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"test-ai-api/stores"
+	"test-ai-api/types"
+	"test-ai-api/utils"
+)
+
+type SearchHandler struct {
+	store       *stores.SearchStore
+	authorStore stores.AuthorRepository
+}
+
+func NewSearchHandler(store *stores.SearchStore, authorStore stores.AuthorRepository) *SearchHandler {
+	return &SearchHandler{store: store, authorStore: authorStore}
+}
+
+// Search handles GET /api/search?q=. Results follow the same visibility
+// rules as the article listing, so drafts only show up for their authors
+// and admins.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := types.SearchQuery{
+		Query:  q.Get("q"),
+		Viewer: requestViewer(r, h.authorStore),
+	}
+
+	var err error
+	if query.Page, query.PerPage, err = parsePage(q); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.store.Search(query)
+	if errors.Is(err, stores.ErrEmptySearch) {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	page.Links = pageLinks(r, page)
+	utils.RespondWithJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"test-ai-api/stores"
+	"test-ai-api/types"
+	"test-ai-api/utils"
+)
+
+type TagHandler struct {
+	*termHandler[types.Tag, types.TagCreate, types.TagUpdate]
+	authorStore stores.AuthorRepository
+}
+
+func NewTagHandler(store stores.TagRepository, authorStore stores.AuthorRepository) *TagHandler {
+	return &TagHandler{
+		termHandler: &termHandler[types.Tag, types.TagCreate, types.TagUpdate]{
+			store:      store,
+			noun:       "Tag",
+			plural:     "tags",
+			id:         func(t types.Tag) int64 { return t.ID },
+			createName: func(t types.TagCreate) string { return t.Name },
+			updateName: func(t types.TagUpdate) string { return t.Name },
+		},
+		authorStore: authorStore,
+	}
+}
+
+// Create lets any author add a tag while writing; renaming and deleting
+// tags is left to admins.
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(int64)
+	isAdmin, _ := r.Context().Value("isAdmin").(bool)
+	if !isAdmin {
+		if _, err := h.authorStore.GetByUserID(userID); err != nil {
+			utils.RespondWithError(w, http.StatusForbidden, "Only authors can create tags")
+			return
+		}
+	}
+
+	h.create(w, r)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"test-ai-api/stores"
+	"test-ai-api/utils"
+)
+
+// termRepository is what tags and categories have in common: T is the term,
+// C and U the bodies accepted to create and update one.
+type termRepository[T, C, U any] interface {
+	Create(C) (T, error)
+	GetBySlug(slug string) (T, error)
+	GetAll() ([]T, error)
+	Update(id int64, input U) (T, error)
+	Delete(id int64) error
+}
+
+// termHandler serves the endpoints shared by tags and categories. Who may
+// create a term differs between them, so each handler checks that before
+// calling create.
+type termHandler[T, C, U any] struct {
+	store termRepository[T, C, U]
+	// noun and plural name the term in messages, like "Tag" and "tags".
+	noun, plural string
+	id           func(T) int64
+	createName   func(C) string
+	updateName   func(U) string
+}
+
+func (h *termHandler[T, C, U]) GetAll(w http.ResponseWriter, r *http.Request) {
+	terms, err := h.store.GetAll()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, terms)
+}
+
+func (h *termHandler[T, C, U]) GetBySlug(w http.ResponseWriter, r *http.Request) {
+	term, err := h.store.GetBySlug(r.PathValue("slug"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, h.noun+" not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, term)
+}
+
+func (h *termHandler[T, C, U]) create(w http.ResponseWriter, r *http.Request) {
+	var input C
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if strings.TrimSpace(h.createName(input)) == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing required fields")
+		return
+	}
+
+	result, err := h.store.Create(input)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, result)
+}
+
+func (h *termHandler[T, C, U]) Update(w http.ResponseWriter, r *http.Request) {
+	if isAdmin, _ := r.Context().Value("isAdmin").(bool); !isAdmin {
+		utils.RespondWithError(w, http.StatusForbidden, "Only admins can update "+h.plural)
+		return
+	}
+
+	var input U
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if strings.TrimSpace(h.updateName(input)) == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Missing required fields")
+		return
+	}
+
+	existing, err := h.store.GetBySlug(r.PathValue("slug"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, h.noun+" not found")
+		return
+	}
+
+	updated, err := h.store.Update(h.id(existing), input)
+	if err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, updated)
+}
+
+func (h *termHandler[T, C, U]) Delete(w http.ResponseWriter, r *http.Request) {
+	if isAdmin, _ := r.Context().Value("isAdmin").(bool); !isAdmin {
+		utils.RespondWithError(w, http.StatusForbidden, "Only admins can delete "+h.plural)
+		return
+	}
+
+	existing, err := h.store.GetBySlug(r.PathValue("slug"))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusNotFound, h.noun+" not found")
+		return
+	}
+
+	if err := h.store.Delete(h.id(existing)); err != nil {
+		h.respondWithError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": h.noun + " deleted successfully"})
+}
+
+// respondWithError maps store errors shared by tags and categories.
+func (h *termHandler[T, C, U]) respondWithError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		utils.RespondWithError(w, http.StatusNotFound, h.noun+" not found")
+	case errors.Is(err, stores.ErrSlugTaken):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, stores.ErrInvalidSlug):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,232 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Formats maps the format names image.DecodeConfig reports for the
+// uploads we accept to their file extension and MIME type. Everything is
+// decoded in pure Go.
+var Formats = map[string]struct{ Ext, MimeType string }{
+	"png":  {".png", "image/png"},
+	"jpeg": {".jpg", "image/jpeg"},
+	"gif":  {".gif", "image/gif"},
+	"webp": {".webp", "image/webp"},
+}
+
+// ErrUnsupported means the bytes are not an image in one of Formats,
+// whatever the file is called.
+var ErrUnsupported = errors.New("unsupported image format")
+
+// SizeError means an image has too many pixels to decode safely.
+type SizeError struct {
+	Width, Height int
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("image is %dx%d pixels, too large to process", e.Width, e.Height)
+}
+
+type Config struct {
+	// Widths are the derivative widths offered for each uploaded image.
+	// Only widths narrower than the original are used.
+	Widths []int
+	// JPEGQuality is used when encoding JPEG derivatives.
+	JPEGQuality int
+	// WebPQuality is used when encoding WebP derivatives.
+	WebPQuality int
+	// MaxBytes caps the size of an uploaded file.
+	MaxBytes int64
+	// MaxDimension and MaxPixels bound the images we accept, since we
+	// decode them in full to resize them and a small file can describe
+	// an enormous canvas.
+	MaxDimension int
+	MaxPixels    int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Widths:       []int{320, 768, 1600},
+		JPEGQuality:  82,
+		WebPQuality:  80,
+		MaxBytes:     10 << 20,
+		MaxDimension: 16384,
+		MaxPixels:    50_000_000,
+	}
+}
+
+// ConfigFromEnv builds a Config from IMAGE_* environment variables, falling
+// back to DefaultConfig for anything unset. IMAGE_WIDTHS is a comma
+// separated list such as "320,768,1600".
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+
+	if v := os.Getenv("IMAGE_WIDTHS"); v != "" {
+		var widths []int
+		for _, field := range strings.Split(v, ",") {
+			if w, err := strconv.Atoi(strings.TrimSpace(field)); err == nil && w > 0 {
+				widths = append(widths, w)
+			}
+		}
+		slices.Sort(widths)
+		cfg.Widths = slices.Compact(widths)
+	}
+	if v, err := strconv.Atoi(os.Getenv("IMAGE_JPEG_QUALITY")); err == nil && v >= 1 && v <= 100 {
+		cfg.JPEGQuality = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("IMAGE_WEBP_QUALITY")); err == nil && v >= 1 && v <= 100 {
+		cfg.WebPQuality = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("IMAGE_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		cfg.MaxBytes = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("IMAGE_MAX_DIMENSION")); err == nil && v > 0 {
+		cfg.MaxDimension = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("IMAGE_MAX_PIXELS")); err == nil && v > 0 {
+		cfg.MaxPixels = v
+	}
+
+	return cfg
+}
+
+// Info describes an uploaded image.
+type Info struct {
+	// Format is a key of Formats.
+	Format string
+	// Width and Height are as displayed, after any EXIF rotation.
+	Width, Height int
+}
+
+// Inspect identifies the image in r from its content alone and checks its
+// dimensions against the configured limits. Nothing past the header is
+// decoded, and r is left wherever reading stopped.
+func (c Config) Inspect(r io.ReadSeeker) (Info, error) {
+	head := make([]byte, 512) // all http.DetectContentType looks at
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Info{}, err
+	}
+	sniffed := http.DetectContentType(head[:n])
+	var format string
+	for name, kind := range Formats {
+		if kind.MimeType == sniffed {
+			format = name
+		}
+	}
+	if format == "" {
+		return Info{}, ErrUnsupported
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Info{}, err
+	}
+	config, decoded, err := image.DecodeConfig(r)
+	if err != nil || decoded != format {
+		return Info{}, fmt.Errorf("%w: not a valid %s", ErrUnsupported, Formats[format].MimeType)
+	}
+
+	info := Info{Format: format, Width: config.Width, Height: config.Height}
+	if format == "jpeg" {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return Info{}, err
+		}
+		if jpegOrientation(r) >= 5 {
+			info.Width, info.Height = info.Height, info.Width
+		}
+	}
+	if err := c.checkSize(info.Width, info.Height); err != nil {
+		return Info{}, err
+	}
+	return info, nil
+}
+
+func (c Config) checkSize(width, height int) error {
+	if width <= 0 || height <= 0 || width > c.MaxDimension || height > c.MaxDimension || width*height > c.MaxPixels {
+		return &SizeError{Width: width, Height: height}
+	}
+	return nil
+}
+
+// Offers reports whether width is one of the configured derivative widths
+// for an image originalWidth pixels wide.
+func (c Config) Offers(width, originalWidth int) bool {
+	return width < originalWidth && slices.Contains(c.Widths, width)
+}
+
+// encodeWebP writes img as a lossy WebP. There is no pure-Go encoder, so it
+// is nil unless the binary is built with -tags webp; see webp.go.
+var encodeWebP func(w io.Writer, img image.Image, quality int) error
+
+// VariantFormat is the extension and MIME type derivatives of an image with
+// the given MIME type are stored as. With a WebP encoder built in, every
+// derivative is WebP, which keeps transparency and is smaller than either
+// alternative. Otherwise JPEGs stay JPEG and everything else, which may
+// carry transparency, becomes PNG.
+func VariantFormat(mimeType string) (ext, variantType string) {
+	if encodeWebP != nil {
+		return ".webp", "image/webp"
+	}
+	if mimeType == "image/jpeg" {
+		return ".jpg", "image/jpeg"
+	}
+	return ".png", "image/png"
+}
+
+// VariantHeight is the height of a derivative width pixels wide, keeping
+// the original's aspect ratio.
+func VariantHeight(width, originalWidth, originalHeight int) int {
+	return max(1, (originalHeight*width+originalWidth/2)/originalWidth)
+}
+
+// Resize decodes the image in r and writes a copy scaled to width pixels
+// wide, encoded as variantType. originalWidth and originalHeight are the
+// stored dimensions, checked before anything is decoded. JPEGs are turned
+// upright first, as the copy carries no EXIF orientation.
+func (c Config) Resize(w io.Writer, r io.Reader, width, originalWidth, originalHeight int, variantType string) error {
+	if err := c.checkSize(originalWidth, originalHeight); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if format == "jpeg" {
+		src = orient(src, jpegOrientation(bytes.NewReader(data)))
+	}
+
+	bounds := src.Bounds()
+	height := VariantHeight(width, bounds.Dx(), bounds.Dy())
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	switch variantType {
+	case "image/webp":
+		if encodeWebP == nil {
+			return fmt.Errorf("%w: built without a WebP encoder", ErrUnsupported)
+		}
+		return encodeWebP(w, dst, c.WebPQuality)
+	case "image/jpeg":
+		return jpeg.Encode(w, dst, &jpeg.Options{Quality: c.JPEGQuality})
+	}
+	return png.Encode(w, dst)
+}
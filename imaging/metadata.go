@@ -0,0 +1,488 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+)
+
+var errMalformed = errors.New("malformed image")
+
+// StripMetadata copies an image in format (a key of Formats) from r to w
+// without EXIF, XMP, IPTC, comments or text chunks, which is where cameras
+// and editors put GPS positions, device serials and author names. Pixel
+// data is copied untouched, so nothing is re-encoded. A JPEG's EXIF
+// orientation is the one tag kept, as browsers need it to show the image
+// the right way up.
+//
+// The image is streamed rather than read into memory: at most one JPEG
+// segment is held at a time. A WebP is read twice, since its header
+// records the size of what is kept.
+func StripMetadata(format string, w io.Writer, r io.ReadSeeker) error {
+	out := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case "jpeg":
+		err = stripJPEG(out, bufio.NewReader(r))
+	case "png":
+		err = stripPNG(out, bufio.NewReader(r))
+	case "gif":
+		err = stripGIF(out, bufio.NewReader(r))
+	case "webp":
+		err = stripWebP(out, r)
+	default:
+		return ErrUnsupported
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = errMalformed
+	}
+	if errors.Is(err, errMalformed) {
+		return fmt.Errorf("%w: %s: %v", ErrUnsupported, Formats[format].MimeType, err)
+	}
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// JPEG markers.
+const (
+	jpegAPP1 = 0xe1 // EXIF and XMP
+	jpegAPPD = 0xed // IPTC and Photoshop resources
+	jpegCOM  = 0xfe
+	jpegSOS  = 0xda
+	jpegEOI  = 0xd9
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// jpegSegments reads the marker segments before the image data starts,
+// calling fn with each, and leaves r at the start-of-scan marker.
+func jpegSegments(r *bufio.Reader, fn func(marker byte, segment []byte) error) error {
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(r, soi); err != nil {
+		return err
+	}
+	if soi[0] != 0xff || soi[1] != 0xd8 {
+		return errMalformed
+	}
+	for {
+		head, err := r.Peek(2)
+		for err == nil && head[0] == 0xff && head[1] == 0xff {
+			r.Discard(1) // fill bytes
+			head, err = r.Peek(2)
+		}
+		if err != nil {
+			return err
+		}
+		if head[0] != 0xff {
+			return errMalformed
+		}
+		marker := head[1]
+		if marker == jpegSOS || marker == jpegEOI {
+			return nil
+		}
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			r.Discard(2)
+			if err := fn(marker, []byte{0xff, marker}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if head, err = r.Peek(4); err != nil {
+			return err
+		}
+		length := int(binary.BigEndian.Uint16(head[2:]))
+		if length < 2 {
+			return errMalformed
+		}
+		segment := make([]byte, 2+length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return err
+		}
+		if err := fn(marker, segment); err != nil {
+			return err
+		}
+	}
+}
+
+func stripJPEG(w io.Writer, r *bufio.Reader) error {
+	if _, err := w.Write([]byte{0xff, 0xd8}); err != nil {
+		return err
+	}
+	err := jpegSegments(r, func(marker byte, segment []byte) error {
+		switch {
+		case marker == jpegAPP1 && len(segment) > 4 && bytes.HasPrefix(segment[4:], exifHeader):
+			if o := exifOrientation(segment[4+len(exifHeader):]); o != 1 {
+				_, err := w.Write(orientationSegment(o))
+				return err
+			}
+			return nil
+		case marker == jpegAPP1, marker == jpegAPPD, marker == jpegCOM:
+			return nil
+		}
+		_, err := w.Write(segment)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// jpegOrientation is the EXIF orientation of the JPEG in r, 1 (upright) if
+// it has none.
+func jpegOrientation(r io.Reader) int {
+	orientation := 1
+	jpegSegments(bufio.NewReader(r), func(marker byte, segment []byte) error {
+		if marker == jpegAPP1 && len(segment) > 4 && bytes.HasPrefix(segment[4:], exifHeader) {
+			orientation = exifOrientation(segment[4+len(exifHeader):])
+		}
+		return nil
+	})
+	return orientation
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF
+// structure, the body of an EXIF segment.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := range count {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		// Orientation is tag 0x0112, a single SHORT.
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+		}
+	}
+	return 1
+}
+
+// orientationSegment is an APP1 segment holding an EXIF structure with
+// nothing but the orientation tag.
+func orientationSegment(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // header, first IFD at offset 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0,
+		0, 0, 0, 0, // no next IFD
+	}
+	segment := []byte{0xff, jpegAPP1, 0, 0}
+	segment = append(segment, exifHeader...)
+	segment = append(segment, tiff...)
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(segment)-2))
+	return segment
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadata lists the chunks dropped from PNGs.
+var pngMetadata = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(w io.Writer, r *bufio.Reader) error {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil {
+		return err
+	}
+	if !bytes.Equal(signature, pngSignature) {
+		return errMalformed
+	}
+	if _, err := w.Write(signature); err != nil {
+		return err
+	}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return err
+		}
+		length := int64(binary.BigEndian.Uint32(header))
+		if length > 1<<31-1 {
+			return errMalformed
+		}
+		kind := string(header[4:])
+		body := length + 4 // data and CRC
+		if pngMetadata[kind] {
+			if _, err := io.CopyN(io.Discard, r, body); err != nil {
+				return err
+			}
+		} else {
+			if _, err := w.Write(header); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(w, r, body); err != nil {
+				return err
+			}
+		}
+		if kind == "IEND" {
+			return nil
+		}
+	}
+}
+
+// gifSubBlocks copies the data sub-blocks at the start of r to w, up to
+// and including the empty one that ends them.
+func gifSubBlocks(w io.Writer, r *bufio.Reader) error {
+	for {
+		size, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{size}); err != nil {
+			return err
+		}
+		if size == 0 {
+			return nil
+		}
+		if _, err := io.CopyN(w, r, int64(size)); err != nil {
+			return err
+		}
+	}
+}
+
+// gifKeepApplications are the application extensions that affect how a
+// GIF plays. Any other, such as XMP, is dropped.
+var gifKeepApplications = map[string]bool{"NETSCAPE2.0": true, "ANIMEXTS1.0": true}
+
+func stripGIF(w io.Writer, r *bufio.Reader) error {
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if flags := header[10]; flags&0x80 != 0 {
+		if _, err := io.CopyN(w, r, 3<<(flags&0x07+1)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case 0x21: // extension
+			label, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			keep := true
+			switch label {
+			case 0xfe: // comment
+				keep = false
+			case 0xff: // application, named by its first sub-block
+				id, err := r.Peek(12)
+				if err != nil {
+					return err
+				}
+				keep = gifKeepApplications[string(id[1:])]
+			}
+			dst := io.Discard
+			if keep {
+				if _, err := w.Write([]byte{kind, label}); err != nil {
+					return err
+				}
+				dst = w
+			}
+			if err := gifSubBlocks(dst, r); err != nil {
+				return err
+			}
+		case 0x2c: // image descriptor
+			descriptor := make([]byte, 10)
+			descriptor[0] = kind
+			if _, err := io.ReadFull(r, descriptor[1:]); err != nil {
+				return err
+			}
+			if _, err := w.Write(descriptor); err != nil {
+				return err
+			}
+			table := int64(0)
+			if flags := descriptor[9]; flags&0x80 != 0 {
+				table = 3 << (flags&0x07 + 1)
+			}
+			// The local color table, then the LZW code size.
+			if _, err := io.CopyN(w, r, table+1); err != nil {
+				return err
+			}
+			if err := gifSubBlocks(w, r); err != nil {
+				return err
+			}
+		case 0x3b: // trailer
+			_, err := w.Write([]byte{kind})
+			return err
+		default:
+			return errMalformed
+		}
+	}
+}
+
+// VP8X flags saying EXIF and XMP chunks are present.
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// webpMetadata lists the chunks dropped from WebPs.
+var webpMetadata = map[string]bool{"EXIF": true, "XMP ": true}
+
+// webpChunks calls fn with the header of each chunk in r between the RIFF
+// header and end, and a reader for its body and padding. Whatever fn
+// leaves unread is skipped.
+func webpChunks(r io.ReadSeeker, end int64, fn func(header []byte, body io.Reader) error) error {
+	header := make([]byte, 8)
+	pos := int64(12)
+	for pos+8 <= end {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, header); err != nil {
+			return err
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:]))
+		next := pos + 8 + size + size&1 // chunks are padded to an even size
+		if next > end {
+			return errMalformed
+		}
+		if err := fn(header, io.LimitReader(r, next-pos-8)); err != nil {
+			return err
+		}
+		pos = next
+	}
+	if pos != end {
+		return errMalformed
+	}
+	return nil
+}
+
+func stripWebP(w io.Writer, r io.ReadSeeker) error {
+	length, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:]) != "WEBP" {
+		return errMalformed
+	}
+	end := 8 + int64(binary.LittleEndian.Uint32(header[4:]))
+	if end > length || end < 12 {
+		return errMalformed
+	}
+
+	// The RIFF header holds the size of everything after it, so the
+	// chunks are walked once to add up what is kept before any is copied.
+	kept := int64(4) // "WEBP"
+	err = webpChunks(r, end, func(header []byte, body io.Reader) error {
+		if !webpMetadata[string(header[:4])] {
+			size := int64(binary.LittleEndian.Uint32(header[4:]))
+			kept += 8 + size + size&1
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(header[4:], uint32(kept))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	return webpChunks(r, end, func(header []byte, body io.Reader) error {
+		kind := string(header[:4])
+		if webpMetadata[kind] {
+			return nil
+		}
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		if kind != "VP8X" {
+			_, err := io.Copy(w, body)
+			return err
+		}
+		chunk, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		if len(chunk) > 0 {
+			chunk[0] &^= webpFlagEXIF | webpFlagXMP
+		}
+		_, err = w.Write(chunk)
+		return err
+	})
+}
+
+// orient returns src turned the way up its EXIF orientation says it should
+// be shown.
+func orient(src image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	in := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(in, in.Bounds(), src, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if orientation >= 5 {
+		out = image.NewNRGBA(image.Rect(0, 0, h, w))
+	}
+	for y := range h {
+		for x := range w {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // upside down
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				dx, dy = x, h-1-y
+			case 5: // mirrored, on its side
+				dx, dy = y, x
+			case 6: // rotated 90° anticlockwise
+				dx, dy = h-1-y, x
+			case 7: // mirrored, on its other side
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° clockwise
+				dx, dy = y, w-1-x
+			}
+			copy(out.Pix[out.PixOffset(dx, dy):][:4], in.Pix[in.PixOffset(x, y):][:4])
+		}
+	}
+	return out
+}
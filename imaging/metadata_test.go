@@ -0,0 +1,210 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// secret stands in for a GPS position or camera serial: it must not
+// survive stripping anywhere in the file.
+const secret = "51.5007N 0.1246W SN#4411"
+
+func testImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := range 20 {
+		for x := range 40 {
+			img.Set(x, y, color.NRGBA{uint8(x * 6), uint8(y * 12), 100, 255})
+		}
+	}
+	return img
+}
+
+// exifTIFF is an EXIF body holding an orientation tag and a pointer to a
+// GPS IFD, followed by secret.
+func exifTIFF(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8,
+		0, 2,
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0,
+		0x88, 0x25, 0, 4, 0, 0, 0, 1, 0, 0, 0, 38, // GPS IFD
+		0, 0, 0, 0,
+	}
+	return append(tiff, secret...)
+}
+
+func jpegSegment(marker byte, body []byte) []byte {
+	segment := []byte{0xff, marker, 0, 0}
+	segment = append(segment, body...)
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(segment)-2))
+	return segment
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func strip(t *testing.T, format string, data []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := StripMetadata(format, &out, bytes.NewReader(data)); err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if bytes.Contains(out.Bytes(), []byte(secret)) {
+		t.Errorf("stripped %s still contains the metadata", format)
+	}
+	return out.Bytes()
+}
+
+func TestStripJPEG(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	plain := encoded.Bytes()
+
+	data := append([]byte(nil), plain[:2]...)
+	data = append(data, jpegSegment(jpegAPP1, append([]byte("Exif\x00\x00"), exifTIFF(6)...))...)
+	data = append(data, jpegSegment(jpegAPP1, []byte("http://ns.adobe.com/xap/1.0/\x00"+secret))...)
+	data = append(data, jpegSegment(jpegAPPD, []byte("Photoshop 3.0\x00"+secret))...)
+	data = append(data, jpegSegment(jpegCOM, []byte(secret))...)
+	data = append(data, plain[2:]...)
+
+	out := strip(t, "jpeg", data)
+
+	var markers []byte
+	err := jpegSegments(bufio.NewReader(bytes.NewReader(out)), func(marker byte, segment []byte) error {
+		markers = append(markers, marker)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(markers, []byte{jpegAPP1}) != 1 || bytes.Contains(markers, []byte{jpegAPPD}) || bytes.Contains(markers, []byte{jpegCOM}) {
+		t.Errorf("segments left: % x, want one APP1 and no APP13 or COM", markers)
+	}
+	if o := jpegOrientation(bytes.NewReader(out)); o != 6 {
+		t.Errorf("orientation = %d, want 6 kept", o)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("stripped JPEG does not decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 20 {
+		t.Errorf("decoded %v, want 40x20", b)
+	}
+	info, err := DefaultConfig().Inspect(bytes.NewReader(out))
+	if err != nil || info.Width != 20 || info.Height != 40 {
+		t.Errorf("Inspect = %+v, %v; want 20x40 once rotated", info, err)
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	plain := encoded.Bytes()
+	ihdrEnd := len(pngSignature) + 25
+	iend := len(plain) - 12
+
+	data := append([]byte(nil), plain[:ihdrEnd]...)
+	data = append(data, pngChunk("tEXt", []byte("Author\x00"+secret))...)
+	data = append(data, pngChunk("eXIf", exifTIFF(1))...)
+	data = append(data, plain[ihdrEnd:iend]...)
+	data = append(data, pngChunk("iTXt", []byte("Comment\x00\x00\x00\x00\x00"+secret))...)
+	data = append(data, plain[iend:]...)
+
+	out := strip(t, "png", data)
+	if !bytes.Equal(out, plain) {
+		t.Errorf("stripped PNG differs from the one without metadata")
+	}
+	for pos := len(pngSignature); pos+8 <= len(out); pos += 12 + int(binary.BigEndian.Uint32(out[pos:])) {
+		if kind := string(out[pos+4 : pos+8]); pngMetadata[kind] {
+			t.Errorf("%s chunk left", kind)
+		}
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("stripped PNG does not decode: %v", err)
+	}
+}
+
+func TestStripGIF(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White})
+	var encoded bytes.Buffer
+	if err := gif.Encode(&encoded, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	plain := encoded.Bytes()
+
+	comment := append([]byte{0x21, 0xfe, byte(len(secret))}, secret...)
+	data := append(append([]byte(nil), plain[:len(plain)-1]...), append(comment, 0, 0x3b)...)
+
+	out := strip(t, "gif", data)
+	if !bytes.Equal(out, plain) {
+		t.Errorf("stripped GIF differs from the one without a comment")
+	}
+}
+
+func TestStripWebP(t *testing.T) {
+	riff := func(chunks ...[]byte) []byte {
+		body := []byte("WEBP")
+		for _, chunk := range chunks {
+			body = append(body, chunk...)
+		}
+		return append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+	}
+	chunk := func(kind string, flags byte, data string) []byte {
+		c := binary.LittleEndian.AppendUint32([]byte(kind), uint32(len(data)))
+		c = append(c, data...)
+		if kind == "VP8X" {
+			c[8] = flags
+		}
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	vp8x := "\x00\x00\x00\x00\x27\x00\x00\x13\x00\x00"
+	bitstream := chunk("VP8L", 0, "not decoded by StripMetadata")
+
+	out := strip(t, "webp", riff(
+		chunk("VP8X", webpFlagEXIF|webpFlagXMP|0x10, vp8x),
+		bitstream,
+		chunk("EXIF", 0, secret+"!"),
+		chunk("XMP ", 0, secret),
+	))
+	want := riff(chunk("VP8X", 0x10, vp8x), bitstream)
+	if !bytes.Equal(out, want) {
+		t.Errorf("stripped WebP =\n% x\nwant\n% x", out, want)
+	}
+}
+
+func TestStripMalformed(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"jpeg", "png", "gif", "webp"} {
+		truncated := encoded.Bytes()[:40]
+		if format == "png" {
+			truncated = encoded.Bytes()[:len(encoded.Bytes())-20]
+		}
+		err := StripMetadata(format, &bytes.Buffer{}, bytes.NewReader(truncated))
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s: StripMetadata = %v, want ErrUnsupported", format, err)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build webp
+
+package imaging
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// Building with -tags webp compiles in libwebp through cgo, so derivatives
+// can be WebP.
+func init() {
+	encodeWebP = func(w io.Writer, img image.Image, quality int) error {
+		return webp.Encode(w, img, &webp.Options{Quality: float32(quality)})
+	}
+}
@@ -0,0 +1,44 @@
+//go:build webp
+
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestWebPVariants(t *testing.T) {
+	for _, mimeType := range []string{"image/jpeg", "image/png", "image/gif", "image/webp"} {
+		if ext, variantType := VariantFormat(mimeType); ext != ".webp" || variantType != "image/webp" {
+			t.Errorf("VariantFormat(%s) = %s, %s; want WebP", mimeType, ext, variantType)
+		}
+	}
+
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	for y := range 200 {
+		for x := range 400 {
+			src.Set(x, y, color.NRGBA{uint8(x), uint8(y), 128, uint8(x / 2)})
+		}
+	}
+	var original bytes.Buffer
+	if err := png.Encode(&original, src); err != nil {
+		t.Fatal(err)
+	}
+
+	var variant bytes.Buffer
+	if err := DefaultConfig().Resize(&variant, &original, 100, 400, 200, "image/webp"); err != nil {
+		t.Fatal(err)
+	}
+	config, err := webp.DecodeConfig(&variant)
+	if err != nil {
+		t.Fatalf("variant is not a WebP: %v", err)
+	}
+	if config.Width != 100 || config.Height != 50 {
+		t.Errorf("variant is %dx%d, want 100x50", config.Width, config.Height)
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"test-ai-api/init/db/dialect"
+	"time"
+)
+
+type Config struct {
+	// Driver selects the backend: "sqlite3" (default) or "postgres".
+	Driver       string
+	DSN          string
+	WAL          bool
+	BusyTimeout  time.Duration
+	ForeignKeys  bool
+	MaxOpenConns int
+	// Seed inserts the demo roles, user, author and articles when the
+	// database has no users yet. It must never be enabled in production.
+	Seed bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Driver:       "sqlite3",
+		DSN:          "./skinny_local.db",
+		WAL:          true,
+		BusyTimeout:  5 * time.Second,
+		ForeignKeys:  true,
+		MaxOpenConns: 10,
+	}
+}
+
+// ConfigFromEnv builds a Config from DB_* environment variables, falling
+// back to DefaultConfig for anything unset.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		cfg.Driver = driver
+	}
+	if dsn := os.Getenv("DB_DSN"); dsn != "" {
+		cfg.DSN = dsn
+	} else if path := os.Getenv("DB_PATH"); path != "" {
+		cfg.DSN = path
+	}
+	if v, err := strconv.ParseBool(os.Getenv("DB_WAL")); err == nil {
+		cfg.WAL = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("DB_BUSY_TIMEOUT")); err == nil {
+		cfg.BusyTimeout = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("DB_FOREIGN_KEYS")); err == nil {
+		cfg.ForeignKeys = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("DB_MAX_OPEN_CONNS")); err == nil {
+		cfg.MaxOpenConns = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("DB_SEED")); err == nil {
+		cfg.Seed = v
+	}
+
+	return cfg
+}
+
+func (c Config) Dialect() (dialect.Dialect, error) {
+	return dialect.For(c.Driver)
+}
+
+// sqliteDSN appends the pragmas as go-sqlite3 connection parameters so
+// they apply to every connection in the pool, not just the first one.
+func (c Config) sqliteDSN() string {
+	params := url.Values{}
+	if c.WAL {
+		params.Set("_journal_mode", "WAL")
+	}
+	if c.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(c.BusyTimeout.Milliseconds(), 10))
+	}
+	if c.ForeignKeys {
+		params.Set("_foreign_keys", "on")
+	} else {
+		params.Set("_foreign_keys", "off")
+	}
+	// Transactions read before they write. Taking the write lock at BEGIN
+	// makes a concurrent one wait out the busy timeout instead of failing
+	// with "database is locked" when it upgrades its read lock.
+	params.Set("_txlock", "immediate")
+
+	sep := "?"
+	if strings.Contains(c.DSN, "?") {
+		sep = "&"
+	}
+	return c.DSN + sep + params.Encode()
+}
@@ -2,10 +2,13 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"log"
-	"os"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/init/db/migrations"
 	"time"
 
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -20,116 +23,135 @@ type Article struct {
 	PublishedAt      time.Time
 }
 
-func Open() (*sql.DB, error) {
-	_, err := os.Stat("./skinny_local.db")
-	if err == nil {
-		os.Remove("./skinny_local.db")
-	}
-	db, err := sql.Open("sqlite3", "./skinny_local.db")
+func Connect(cfg Config) (*sql.DB, error) {
+	d, err := cfg.Dialect()
 	if err != nil {
 		return nil, err
 	}
 
-	if err = createTables(db); err != nil {
+	dsn := cfg.DSN
+	if d == dialect.SQLite {
+		dsn = cfg.sqliteDSN()
+	}
+	db, err := sql.Open(d.Driver(), dsn)
+	if err != nil {
 		return nil, err
 	}
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
 
-	if err = seedUsers(db); err != nil {
+	if err = db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
 
-func createTables(db *sql.DB) error {
-	tables := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			first_name TEXT NOT NULL,
-			last_name TEXT NOT NULL,
-			email TEXT UNIQUE NOT NULL,
-			password TEXT NOT NULL,
-			is_admin BOOLEAN NOT NULL DEFAULT FALSE,
-			role_id INTEGER NOT NULL,
-			last_login DATETIME,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			deleted_at DATETIME,
-			FOREIGN KEY (role_id) REFERENCES roles(id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS roles (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			deleted_at DATETIME
-		)`,
-		`CREATE TABLE IF NOT EXISTS authors (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			first_name TEXT NOT NULL,
-			last_name TEXT NOT NULL,
-			slug TEXT UNIQUE NOT NULL,
-			bio TEXT,
-			user_id INTEGER NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			deleted_at DATETIME,
-			FOREIGN KEY (user_id) REFERENCES users(id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS articles (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			title TEXT NOT NULL,
-			slug TEXT UNIQUE NOT NULL,
-			short_description TEXT,
-			content TEXT NOT NULL,
-			status TEXT NOT NULL DEFAULT 'draft',
-			author_id INTEGER NOT NULL,
-			published_at DATETIME,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			deleted_at DATETIME,
-			FOREIGN KEY (author_id) REFERENCES authors(id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS article_images (
-			article_id INTEGER NOT NULL,
-			image_id INTEGER NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (article_id, image_id),
-			FOREIGN KEY (article_id) REFERENCES articles(id),
-			FOREIGN KEY (image_id) REFERENCES images(id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS images (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			url TEXT NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			deleted_at DATETIME
-		)`,
-	}
-
-	for _, table := range tables {
-		if _, err := db.Exec(table); err != nil {
-			return err
+// searchIndexMigration creates the SQLite full-text search index.
+const searchIndexMigration = 7
+
+// searchIndexObjects counts the table and triggers searchIndexMigration
+// creates, whether or not schema_migrations records it.
+const searchIndexObjects = `
+	SELECT COUNT(*) FROM sqlite_master
+	WHERE name IN ('articles_fts', 'articles_fts_insert', 'articles_fts_update', 'articles_fts_delete', 'authors_fts_update')`
+
+// NewMigrator returns a migrator for the database. When go-sqlite3 was
+// compiled without FTS5 the search index is left pending, to be built by
+// the first run of a binary that has it, and search falls back to plain
+// substring matching. A database that already has the index can't be
+// opened by such a binary at all: its triggers would fail every write to
+// articles with "no such module: fts5".
+func NewMigrator(db *sql.DB, d dialect.Dialect) (*migrations.Migrator, error) {
+	migrator, err := migrations.New(db, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if d == dialect.SQLite {
+		var fts5 bool
+		if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+			return nil, err
+		}
+		if !fts5 {
+			var objects int
+			if err := db.QueryRow(searchIndexObjects).Scan(&objects); err != nil {
+				return nil, err
+			}
+			if objects > 0 {
+				return nil, errors.New("the database has an FTS5 search index but SQLite was built without FTS5; rebuild with -tags sqlite_fts5")
+			}
+			log.Printf("Warning: SQLite was built without FTS5, so article search falls back to substring matching; build with -tags sqlite_fts5")
+			migrator.Defer(searchIndexMigration)
 		}
 	}
 
-	return nil
+	return migrator, nil
 }
 
-func seedUsers(db *sql.DB) error {
+func Open(cfg Config) (*sql.DB, error) {
+	d, err := cfg.Dialect()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db, d)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err = migrator.Up(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if cfg.Seed {
+		if err = Seed(db, d); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+// Seed loads the demo data used for local development. It is a no-op when
+// the database already has users, so it is safe to run on every start.
+func Seed(db *sql.DB, d dialect.Dialect) error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("Skipping seed: database already has %d users", count)
+		return nil
+	}
+
+	return seedUsers(db, d)
+}
+
+func seedUsers(db *sql.DB, d dialect.Dialect) error {
 	// Hash password123 - in production, use bcrypt.GenerateFromPassword
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Exec(`
+	_, err = d.InsertID(db, `
 		INSERT INTO roles (name) VALUES (?)`,
 		"admin",
 	)
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec(`
+	authorRoleID, err := d.InsertID(db, `
 		INSERT INTO roles (name) VALUES (?)`,
 		"author",
 	)
@@ -137,15 +159,15 @@ func seedUsers(db *sql.DB) error {
 		return err
 	}
 
-	result, err := db.Exec(`
+	userID, err := d.InsertID(db, `
 		INSERT INTO users (first_name, last_name, email, password, is_admin, role_id, last_login, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		"John",
 		"Tavener",
 		"john@example.com",
-		hashedPassword,
+		string(hashedPassword),
 		true,
-		2,
+		authorRoleID,
 		time.Now(),
 		time.Now(),
 		time.Now(),
@@ -155,12 +177,7 @@ func seedUsers(db *sql.DB) error {
 		return err
 	}
 
-	userID, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
-
-	_, err = db.Exec(`
+	authorID, err := d.InsertID(db, `
 		INSERT INTO authors (first_name, last_name, slug, bio, user_id, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)`,
 		"John",
@@ -184,7 +201,7 @@ func seedUsers(db *sql.DB) error {
 			ShortDescription: "Short description of article 1",
 			Content:          "Content of article 1",
 			Status:           "published",
-			AuthorID:         userID,
+			AuthorID:         authorID,
 			PublishedAt:      time.Now(),
 		},
 		{
@@ -193,7 +210,7 @@ func seedUsers(db *sql.DB) error {
 			ShortDescription: "Short description of article 2",
 			Content:          "Content of article 2",
 			Status:           "published",
-			AuthorID:         userID,
+			AuthorID:         authorID,
 			PublishedAt:      time.Now(),
 		},
 		{
@@ -202,13 +219,13 @@ func seedUsers(db *sql.DB) error {
 			ShortDescription: "Short description of article 3",
 			Content:          "Content of article 3",
 			Status:           "published",
-			AuthorID:         userID,
+			AuthorID:         authorID,
 			PublishedAt:      time.Now(),
 		},
 	}
 
 	for _, article := range array {
-		_, err = db.Exec(`
+		_, err = d.InsertID(db, `
 			INSERT INTO articles (title, slug, short_description, content, status, author_id, published_at)
 			VALUES (?, ?, ?, ?, ?, ?, ?)`,
 			article.Title,
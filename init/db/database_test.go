@@ -0,0 +1,37 @@
+package db
+
+import (
+	"path/filepath"
+	"test-ai-api/init/db/dialect"
+	"testing"
+)
+
+func TestNewMigratorRefusesSearchIndexWithoutFTS5(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DSN = filepath.Join(t.TempDir(), "test.db")
+	database, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	var fts5 bool
+	if err := database.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		t.Fatal(err)
+	}
+	if fts5 {
+		t.Skip("SQLite was built with FTS5")
+	}
+
+	// Without FTS5 the virtual table can't be created, but a trigger left
+	// behind by a binary that had it is enough to break every insert.
+	if _, err := database.Exec(`
+		CREATE TRIGGER articles_fts_insert AFTER INSERT ON articles BEGIN
+			INSERT INTO articles_fts (rowid, title) VALUES (new.id, new.title);
+		END`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMigrator(database, dialect.SQLite); err == nil {
+		t.Fatal("NewMigrator accepted a database with search index triggers")
+	}
+}
@@ -0,0 +1,108 @@
+package dialect
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx.
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Dialect hides the differences between the SQL backends. Queries are
+// written with ? placeholders and rebound for the target database.
+type Dialect interface {
+	Name() string
+	Driver() string
+	Rebind(query string) string
+	// InsertID runs an INSERT and returns the id of the new row.
+	InsertID(q Querier, query string, args ...any) (int64, error)
+	IsUniqueViolation(err error) bool
+}
+
+func For(driver string) (Dialect, error) {
+	switch driver {
+	case "sqlite3", "sqlite", "":
+		return SQLite, nil
+	case "postgres", "postgresql", "pgx":
+		return Postgres, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+var (
+	SQLite   Dialect = sqliteDialect{}
+	Postgres Dialect = postgresDialect{}
+)
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string   { return "sqlite" }
+func (sqliteDialect) Driver() string { return "sqlite3" }
+
+func (sqliteDialect) Rebind(query string) string { return query }
+
+func (sqliteDialect) InsertID(q Querier, query string, args ...any) (int64, error) {
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string   { return "postgres" }
+func (postgresDialect) Driver() string { return "postgres" }
+
+// Rebind converts ? placeholders to $1, $2, ... skipping quoted strings.
+func (postgresDialect) Rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	inQuote := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			b.WriteByte(c)
+		case c == '?' && !inQuote:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Postgres drivers don't implement LastInsertId, so the insert is run with
+// RETURNING id and the value is scanned from the result row.
+func (d postgresDialect) InsertID(q Querier, query string, args ...any) (int64, error) {
+	var id int64
+	err := q.QueryRow(d.Rebind(query)+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,362 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"test-ai-api/init/db/dialect"
+	"time"
+)
+
+// Each dialect has its own directory of migrations with matching versions.
+//
+//go:embed sqlite/*.sql postgres/*.sql
+var files embed.FS
+
+// Migration is a single versioned schema change loaded from a pair of
+// NNNN_name.up.sql / NNNN_name.down.sql files.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status describes whether a migration has been applied to the database.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	// Deferred is set on a pending migration that this migrator skips.
+	Deferred bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect.Dialect
+	migrations []Migration
+	deferred   map[int]bool
+}
+
+func New(db *sql.DB, d dialect.Dialect) (*Migrator, error) {
+	migrations, err := Load(d)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: migrations, deferred: map[int]bool{}}, nil
+}
+
+// Defer leaves a migration pending while the others are applied around
+// it. It is for optional features the database can't support yet, such as
+// an index needing a SQLite extension; the migration runs on a later Up
+// once nothing defers it. Asking To for a deferred version is an error.
+func (m *Migrator) Defer(version int) {
+	m.deferred[version] = true
+}
+
+// Load reads the embedded migration files for the dialect ordered by version.
+func Load(d dialect.Dialect) ([]Migration, error) {
+	dir, err := fs.Sub(files, d.Name())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", name, err)
+		}
+
+		body, err := fs.ReadFile(dir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", version, m.Name, label)
+		}
+
+		if direction == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s: missing up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)`)
+	return err
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// applied returns the recorded migrations and verifies that none of the
+// embedded files have been edited since they were run.
+func (m *Migrator) applied() (map[int]appliedMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query("SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	known := map[int]bool{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		a, ok := applied[migration.Version]
+		if ok && a.checksum != migration.Checksum {
+			return nil, fmt.Errorf("migration %d_%s: checksum mismatch, file was modified after it was applied", migration.Version, migration.Name)
+		}
+	}
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("migration %d is applied but has no matching file", version)
+		}
+	}
+
+	return applied, nil
+}
+
+// Version returns the highest applied migration version, or 0.
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// Latest returns the highest available migration version.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration that isn't deferred.
+func (m *Migrator) Up() error {
+	return m.migrate(m.Latest())
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down() error {
+	current, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+
+	target := 0
+	for _, migration := range m.migrations {
+		if migration.Version < current {
+			target = migration.Version
+		}
+	}
+	return m.migrate(target)
+}
+
+// To migrates up or down until the given version is the latest applied one.
+// Migrations below target that are deferred are skipped as they are by Up,
+// but target itself must be one that can be applied.
+func (m *Migrator) To(target int) error {
+	if target != 0 && !m.exists(target) {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+	if m.deferred[target] {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		if _, ok := applied[target]; !ok {
+			return fmt.Errorf("migration %d is deferred and cannot be applied by this build", target)
+		}
+	}
+	return m.migrate(target)
+}
+
+func (m *Migrator) migrate(target int) error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok || m.deferred[migration.Version] {
+			continue
+		}
+		if err := m.apply(migration); err != nil {
+			return err
+		}
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if err := m.revert(migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if a, ok := applied[migration.Version]; ok {
+			appliedAt := a.appliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		} else {
+			status.Deferred = m.deferred[migration.Version]
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) exists(version int) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration.Up); err != nil {
+		return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(
+		m.dialect.Rebind("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"),
+		migration.Version, migration.Name, migration.Checksum, time.Now(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) revert(migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration.Down); err != nil {
+		return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(m.dialect.Rebind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
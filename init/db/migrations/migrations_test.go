@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"database/sql"
+	"path/filepath"
+	"test-ai-api/init/db/dialect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, dialect.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDeferredMigration(t *testing.T) {
+	m := newMigrator(t)
+	// Migration 2 only adds indexes, so the ones after it apply without it.
+	m.Defer(2)
+
+	if err := m.To(2); err == nil {
+		t.Fatal("To a deferred version succeeded")
+	}
+	if version, err := m.Version(); err != nil || version != 0 {
+		t.Fatalf("Version after refused To = %d, %v; want 0", version, err)
+	}
+
+	if err := m.To(3); err != nil {
+		t.Fatalf("To past a deferred version: %v", err)
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses[:3] {
+		want := status.Version != 2
+		if status.Applied != want || status.Deferred == want {
+			t.Errorf("migration %d: applied %v, deferred %v", status.Version, status.Applied, status.Deferred)
+		}
+	}
+
+	if err := m.Down(); err != nil {
+		t.Fatalf("Down over a deferred version: %v", err)
+	}
+	if version, err := m.Version(); err != nil || version != 1 {
+		t.Fatalf("Version after Down = %d, %v; want 1", version, err)
+	}
+}
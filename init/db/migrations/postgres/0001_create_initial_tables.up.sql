@@ -0,0 +1,61 @@
+CREATE TABLE IF NOT EXISTS roles (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id BIGSERIAL PRIMARY KEY,
+	first_name TEXT NOT NULL,
+	last_name TEXT NOT NULL,
+	email TEXT UNIQUE NOT NULL,
+	password TEXT NOT NULL,
+	is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+	role_id BIGINT NOT NULL REFERENCES roles(id),
+	last_login TIMESTAMPTZ,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS authors (
+	id BIGSERIAL PRIMARY KEY,
+	first_name TEXT NOT NULL,
+	last_name TEXT NOT NULL,
+	slug TEXT UNIQUE NOT NULL,
+	bio TEXT,
+	user_id BIGINT NOT NULL REFERENCES users(id),
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS articles (
+	id BIGSERIAL PRIMARY KEY,
+	title TEXT NOT NULL,
+	slug TEXT UNIQUE NOT NULL,
+	short_description TEXT,
+	content TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'draft',
+	author_id BIGINT NOT NULL REFERENCES authors(id),
+	published_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS images (
+	id BIGSERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	deleted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS article_images (
+	article_id BIGINT NOT NULL REFERENCES articles(id),
+	image_id BIGINT NOT NULL REFERENCES images(id),
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (article_id, image_id)
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_articles_title;
+DROP INDEX IF EXISTS idx_articles_listing_date;
+DROP INDEX IF EXISTS idx_articles_author_id;
+DROP INDEX IF EXISTS idx_articles_status_published_at;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS slug_redirects (
+	slug TEXT PRIMARY KEY,
+	article_id BIGINT NOT NULL REFERENCES articles(id),
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_slug_redirects_article_id ON slug_redirects (article_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS article_revisions (
+	id BIGSERIAL PRIMARY KEY,
+	article_id BIGINT NOT NULL REFERENCES articles(id),
+	revision INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	slug TEXT NOT NULL,
+	short_description TEXT,
+	content TEXT NOT NULL,
+	status TEXT NOT NULL,
+	editor_id BIGINT REFERENCES users(id),
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (article_id, revision)
+);
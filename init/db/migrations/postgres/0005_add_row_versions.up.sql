@@ -0,0 +1,2 @@
+ALTER TABLE articles ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE authors ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
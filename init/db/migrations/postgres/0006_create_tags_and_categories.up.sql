@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS tags (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	slug TEXT UNIQUE NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	slug TEXT UNIQUE NOT NULL,
+	description TEXT,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS article_tags (
+	article_id BIGINT NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+	tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	PRIMARY KEY (article_id, tag_id)
+);
+
+CREATE TABLE IF NOT EXISTS article_categories (
+	article_id BIGINT NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+	category_id BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+	PRIMARY KEY (article_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_tags_tag_id ON article_tags (tag_id);
+CREATE INDEX IF NOT EXISTS idx_article_categories_category_id ON article_categories (category_id);
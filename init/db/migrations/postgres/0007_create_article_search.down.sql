@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS authors_search_refresh ON authors;
+DROP TRIGGER IF EXISTS articles_search_refresh ON articles;
+DROP FUNCTION IF EXISTS authors_search_refresh();
+DROP FUNCTION IF EXISTS articles_search_refresh();
+DROP TABLE IF EXISTS article_search;
+DROP FUNCTION IF EXISTS article_search_document(BIGINT);
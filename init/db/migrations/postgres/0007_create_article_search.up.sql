@@ -0,0 +1,46 @@
+-- Postgres counterpart of the SQLite FTS5 index: one weighted tsvector per
+-- article, kept in sync by triggers.
+CREATE TABLE IF NOT EXISTS article_search (
+	article_id BIGINT PRIMARY KEY REFERENCES articles(id) ON DELETE CASCADE,
+	document TSVECTOR NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_search_document ON article_search USING GIN (document);
+
+CREATE OR REPLACE FUNCTION article_search_document(target_id BIGINT) RETURNS TSVECTOR AS $$
+	SELECT setweight(to_tsvector('simple', COALESCE(a.title, '')), 'A')
+		|| setweight(to_tsvector('simple', COALESCE(au.first_name || ' ' || au.last_name, '')), 'B')
+		|| setweight(to_tsvector('simple', COALESCE(a.short_description, '')), 'B')
+		|| setweight(to_tsvector('simple', COALESCE(a.content, '')), 'C')
+	FROM articles a
+	LEFT JOIN authors au ON au.id = a.author_id
+	WHERE a.id = target_id
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION articles_search_refresh() RETURNS TRIGGER AS $$
+BEGIN
+	INSERT INTO article_search (article_id, document)
+	VALUES (NEW.id, article_search_document(NEW.id))
+	ON CONFLICT (article_id) DO UPDATE SET document = EXCLUDED.document;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER articles_search_refresh
+AFTER INSERT OR UPDATE OF title, short_description, content, author_id ON articles
+FOR EACH ROW EXECUTE FUNCTION articles_search_refresh();
+
+CREATE OR REPLACE FUNCTION authors_search_refresh() RETURNS TRIGGER AS $$
+BEGIN
+	UPDATE article_search SET document = article_search_document(article_id)
+	WHERE article_id IN (SELECT id FROM articles WHERE author_id = NEW.id);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER authors_search_refresh
+AFTER UPDATE OF first_name, last_name ON authors
+FOR EACH ROW EXECUTE FUNCTION authors_search_refresh();
+
+INSERT INTO article_search (article_id, document)
+SELECT id, article_search_document(id) FROM articles;
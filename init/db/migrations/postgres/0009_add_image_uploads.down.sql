@@ -0,0 +1,6 @@
+ALTER TABLE images DROP COLUMN height;
+ALTER TABLE images DROP COLUMN width;
+ALTER TABLE images DROP COLUMN mime_type;
+ALTER TABLE images DROP COLUMN size;
+ALTER TABLE images DROP COLUMN storage_key;
+ALTER TABLE images DROP COLUMN filename;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS article_images;
+DROP TABLE IF EXISTS images;
+DROP TABLE IF EXISTS articles;
+DROP TABLE IF EXISTS authors;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_articles_status_published_at ON articles (status, published_at);
+CREATE INDEX IF NOT EXISTS idx_articles_author_id ON articles (author_id);
+CREATE INDEX IF NOT EXISTS idx_articles_listing_date ON articles (COALESCE(published_at, created_at), id);
+CREATE INDEX IF NOT EXISTS idx_articles_title ON articles (title, id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS article_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	article_id INTEGER NOT NULL REFERENCES articles(id),
+	revision INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	slug TEXT NOT NULL,
+	short_description TEXT,
+	content TEXT NOT NULL,
+	status TEXT NOT NULL,
+	editor_id INTEGER REFERENCES users(id),
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (article_id, revision)
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS article_categories;
+DROP TABLE IF EXISTS article_tags;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS tags;
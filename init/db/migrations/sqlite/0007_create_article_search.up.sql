@@ -0,0 +1,42 @@
+-- Requires SQLite built with FTS5 (go build -tags sqlite_fts5).
+-- The index is keyed by article id and kept in sync by triggers, so every
+-- write path, including seeds and manual SQL, is searchable.
+CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts5(
+	title,
+	short_description,
+	content,
+	author_name,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_insert AFTER INSERT ON articles BEGIN
+	INSERT INTO articles_fts (rowid, title, short_description, content, author_name)
+	VALUES (
+		new.id, new.title, COALESCE(new.short_description, ''), new.content,
+		COALESCE((SELECT first_name || ' ' || last_name FROM authors WHERE id = new.author_id), '')
+	);
+END;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_update AFTER UPDATE OF title, short_description, content, author_id ON articles BEGIN
+	DELETE FROM articles_fts WHERE rowid = old.id;
+	INSERT INTO articles_fts (rowid, title, short_description, content, author_name)
+	VALUES (
+		new.id, new.title, COALESCE(new.short_description, ''), new.content,
+		COALESCE((SELECT first_name || ' ' || last_name FROM authors WHERE id = new.author_id), '')
+	);
+END;
+
+CREATE TRIGGER IF NOT EXISTS articles_fts_delete AFTER DELETE ON articles BEGIN
+	DELETE FROM articles_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS authors_fts_update AFTER UPDATE OF first_name, last_name ON authors BEGIN
+	UPDATE articles_fts SET author_name = new.first_name || ' ' || new.last_name
+	WHERE rowid IN (SELECT id FROM articles WHERE author_id = new.id);
+END;
+
+INSERT INTO articles_fts (rowid, title, short_description, content, author_name)
+SELECT a.id, a.title, COALESCE(a.short_description, ''), a.content,
+	COALESCE(au.first_name || ' ' || au.last_name, '')
+FROM articles a
+LEFT JOIN authors au ON au.id = a.author_id;
@@ -0,0 +1,6 @@
+ALTER TABLE images ADD COLUMN filename TEXT NOT NULL DEFAULT '';
+ALTER TABLE images ADD COLUMN storage_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE images ADD COLUMN size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE images ADD COLUMN mime_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE images ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE images ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
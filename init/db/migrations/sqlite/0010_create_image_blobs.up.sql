@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS image_blobs (
+	storage_key TEXT PRIMARY KEY,
+	sha256 TEXT UNIQUE,
+	ref_count INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Uploads from before hashing have no sha256, so they are never shared,
+-- but deleting them still frees the blob.
+INSERT INTO image_blobs (storage_key, ref_count)
+SELECT storage_key, COUNT(*)
+FROM images
+WHERE storage_key <> '' AND deleted_at IS NULL
+GROUP BY storage_key;
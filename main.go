@@ -1,20 +1,80 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"test-ai-api/imaging"
 	"test-ai-api/init/db"
 	"test-ai-api/routes"
+	"test-ai-api/scheduler"
+	"test-ai-api/site"
+	"test-ai-api/storage"
+	"test-ai-api/stores"
+	"time"
 )
 
 func main() {
-	database, err := db.Open()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "seed":
+			if err := runSeed(); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "migrate":
+			if err := runMigrate(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "export-static":
+			if err := runExportStatic(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		default:
+			log.Fatalf("unknown command %q", os.Args[1])
+		}
+	}
+
+	cfg := db.ConfigFromEnv()
+	d, err := cfg.Dialect()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	database, err := db.Open(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer database.Close()
 
-	handler := routes.SetupRoutes(database)
+	if err := stores.VerifySchema(database); err != nil {
+		log.Fatal(err)
+	}
+
+	interval, err := time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = time.Minute
+	}
+	go scheduler.New(stores.NewArticleStore(database, d), interval).Run(context.Background())
+
+	blobs, err := storage.Open(storage.ConfigFromEnv())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	siteConfig := site.ConfigFromEnv()
+	if siteConfig.URL == "" {
+		log.Printf("Warning: SITE_URL is not set, so /sitemap.xml is not served")
+	}
+	theme, err := site.LoadTheme(siteConfig.ThemeDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	handler := routes.SetupRoutes(database, d, blobs, imaging.ConfigFromEnv(), siteConfig, theme)
 	log.Printf("Server starting on :8080")
 	log.Fatal(http.ListenAndServe(":8080", handler))
 }
@@ -0,0 +1,49 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// converter renders CommonMark plus the GitHub extensions: tables,
+// strikethrough, autolinks and task lists. Raw HTML is passed through and
+// left for the sanitizer to clean up. Table alignment is written as align
+// attributes because the sanitizer strips inline styles.
+var converter = goldmark.New(
+	goldmark.WithExtensions(
+		extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+		extension.Strikethrough,
+		extension.Linkify,
+		extension.TaskList,
+	),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// policy is the allowlist applied to every rendered document. It starts
+// from bluemonday's user-generated-content policy, which drops scripts,
+// event handlers and javascript: URLs, and adds what the renderer needs:
+// language classes on fenced code, table alignment and task list boxes.
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}()
+
+// Render converts Markdown source to sanitized HTML.
+func Render(source string) string {
+	var buf bytes.Buffer
+	if err := converter.Convert([]byte(source), &buf); err != nil {
+		// goldmark only fails when the writer does; fall back to escaped
+		// text rather than dropping the content.
+		return policy.Sanitize(bluemonday.StrictPolicy().Sanitize(source))
+	}
+	return policy.Sanitize(buf.String())
+}
@@ -15,22 +15,52 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Bearer token format
-		bearerToken := strings.Split(authHeader, " ")
-		if len(bearerToken) != 2 {
-			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token format")
+		ctx, message := authenticate(r.Context(), authHeader)
+		if message != "" {
+			utils.RespondWithError(w, http.StatusUnauthorized, message)
 			return
 		}
 
-		claims, err := utils.ValidateJWT(bearerToken[1])
-		if err != nil {
-			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid token")
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// OptionalAuthMiddleware is for public routes whose response depends on who
+// is asking. Requests without a token pass through anonymously, but a token
+// that is present must still be valid.
+func OptionalAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, message := authenticate(r.Context(), authHeader)
+		if message != "" {
+			utils.RespondWithError(w, http.StatusUnauthorized, message)
 			return
 		}
 
-		// Add user ID to context
-		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-		ctx = context.WithValue(ctx, "role", claims.Role)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
+
+func authenticate(ctx context.Context, authHeader string) (context.Context, string) {
+	// Bearer token format
+	bearerToken := strings.Split(authHeader, " ")
+	if len(bearerToken) != 2 {
+		return ctx, "Invalid token format"
+	}
+
+	claims, err := utils.ValidateJWT(bearerToken[1])
+	if err != nil {
+		return ctx, "Invalid token"
+	}
+
+	// Add user ID to context
+	ctx = context.WithValue(ctx, "userID", claims.UserID)
+	ctx = context.WithValue(ctx, "role", claims.Role)
+	ctx = context.WithValue(ctx, "isAdmin", claims.IsAdmin)
+	return ctx, ""
+}
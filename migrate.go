@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"test-ai-api/init/db"
+	"text/tabwriter"
+)
+
+const migrateUsage = "usage: migrate up|down|status|to N"
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	cfg := db.ConfigFromEnv()
+	d, err := cfg.Dialect()
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	migrator, err := db.NewMigrator(database, d)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return migrator.Up()
+	case "down":
+		return migrator.Down()
+	case "to":
+		if len(args) != 2 {
+			return fmt.Errorf(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return migrator.To(version)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			} else if status.Deferred {
+				appliedAt = "deferred"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+}
+
+func runSeed() error {
+	cfg := db.ConfigFromEnv()
+	d, err := cfg.Dialect()
+	if err != nil {
+		return err
+	}
+
+	database, err := db.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	return db.Seed(database, d)
+}
@@ -0,0 +1,65 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	doc := `{"title":"Old","tags":["a","b"],"meta":{"n":1}}`
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{"replace field", `[{"op":"replace","path":"/title","value":"New"}]`,
+			`{"meta":{"n":1},"tags":["a","b"],"title":"New"}`, nil},
+		{"replace whole document", `[{"op":"replace","path":"","value":{"title":"Root"}}]`,
+			`{"title":"Root"}`, nil},
+		{"replace document then field", `[{"op":"replace","path":"","value":{"title":"Root"}},{"op":"replace","path":"/title","value":"Again"}]`,
+			`{"title":"Again"}`, nil},
+		{"replace missing field", `[{"op":"replace","path":"/missing","value":1}]`, "", ErrPathNotFound},
+		{"add to array end", `[{"op":"add","path":"/tags/-","value":"c"}]`,
+			`{"meta":{"n":1},"tags":["a","b","c"],"title":"Old"}`, nil},
+		{"remove array item", `[{"op":"remove","path":"/tags/0"}]`,
+			`{"meta":{"n":1},"tags":["b"],"title":"Old"}`, nil},
+		{"remove whole document", `[{"op":"remove","path":""}]`, "", ErrInvalidPatch},
+		{"move", `[{"op":"move","from":"/meta/n","path":"/count"}]`,
+			`{"count":1,"meta":{},"tags":["a","b"],"title":"Old"}`, nil},
+		{"copy", `[{"op":"copy","from":"/title","path":"/name"}]`,
+			`{"meta":{"n":1},"name":"Old","tags":["a","b"],"title":"Old"}`, nil},
+		{"test passes", `[{"op":"test","path":"/meta","value":{"n":1.0}}]`, doc, nil},
+		{"test fails", `[{"op":"test","path":"/title","value":"New"}]`, "", ErrTestFailed},
+		{"test whole document", `[{"op":"test","path":"","value":{"title":"Old","tags":["a","b"],"meta":{"n":1}}}]`, doc, nil},
+		{"missing path", `[{"op":"add","value":1}]`, "", ErrInvalidPatch},
+		{"unknown op", `[{"op":"frob","path":"/title"}]`, "", ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		got, err := Apply([]byte(doc), []byte(tt.patch))
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		want, _ := Merge([]byte(tt.want), []byte(`{}`))
+		if string(got) != string(want) {
+			t.Errorf("%s: got %s, want %s", tt.name, got, want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got, err := Merge([]byte(`{"a":1,"b":{"c":2,"d":3}}`), []byte(`{"a":null,"b":{"c":4},"e":5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"b":{"c":4,"d":3},"e":5}`; string(got) != want {
+		t.Errorf("Merge = %s, want %s", got, want)
+	}
+}
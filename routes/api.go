@@ -4,21 +4,25 @@ import (
 	"database/sql"
 	"net/http"
 	"test-ai-api/handlers"
+	"test-ai-api/imaging"
+	"test-ai-api/init/db/dialect"
 	"test-ai-api/middleware"
+	"test-ai-api/site"
+	"test-ai-api/storage"
 	"test-ai-api/stores"
 )
 
-func SetupRoutes(db *sql.DB) http.Handler {
+func SetupRoutes(db *sql.DB, d dialect.Dialect, blobs storage.BlobStorage, images imaging.Config, siteConfig site.Config, theme *site.Theme) http.Handler {
 	mux := http.NewServeMux()
 
-	userStore := stores.NewUserStore(db)
+	userStore := stores.NewUserStore(db, d)
 	authHandler := handlers.NewAuthHandler(userStore)
 
 	// Public routes
 	mux.HandleFunc("POST /api/login", authHandler.Login)
 	mux.HandleFunc("POST /api/register", authHandler.Register)
 
-	authorStore := stores.NewAuthorStore(db)
+	authorStore := stores.NewAuthorStore(db, d)
 	authorHandler := handlers.NewAuthorHandler(authorStore)
 
 	// Protected routes
@@ -26,32 +30,114 @@ func SetupRoutes(db *sql.DB) http.Handler {
 	mux.HandleFunc("GET /api/authors/{slug}", authorHandler.GetBySlug)
 	mux.HandleFunc("POST /api/authors", middleware.AuthMiddleware(authorHandler.Create))
 	mux.HandleFunc("PUT /api/authors/{slug}", middleware.AuthMiddleware(authorHandler.Update))
+	mux.HandleFunc("PATCH /api/authors/{slug}", middleware.AuthMiddleware(authorHandler.Patch))
 	mux.HandleFunc("DELETE /api/authors/{slug}", middleware.AuthMiddleware(authorHandler.Delete))
 
-	imageStore := stores.NewImageStore(db)
-	imageHandler := handlers.NewImageHandler(imageStore)
+	imageStore := stores.NewImageStore(db, d, blobs)
+	imageHandler := handlers.NewImageHandler(imageStore, images)
 
 	// public routes
 	mux.HandleFunc("GET /api/images/{id}", imageHandler.GetById)
 
+	mediaHandler := handlers.NewMediaHandler(imageStore, blobs, images)
+	mux.HandleFunc("GET /media/{id}/{filename}", mediaHandler.Serve)
+	mux.HandleFunc("GET /media/{id}/{width}/{filename}", mediaHandler.ServeVariant)
+
 	// Protected routes
 	mux.HandleFunc("POST /api/images", middleware.AuthMiddleware(imageHandler.Create))
 	mux.HandleFunc("DELETE /api/images/{id}", middleware.AuthMiddleware(imageHandler.Delete))
 
-	articleStore := stores.NewArticleStore(db)
+	articleStore := stores.NewArticleStore(db, d)
 	articleHandler := handlers.NewArticleHandler(articleStore, authorStore)
 
 	// Public routes
-	mux.HandleFunc("GET /api/articles", articleHandler.GetAll)
-	mux.HandleFunc("GET /api/articles/{id}", articleHandler.GetByID)
+	mux.HandleFunc("GET /api/articles", middleware.OptionalAuthMiddleware(articleHandler.GetAll))
+	mux.HandleFunc("GET /api/articles/{id}", middleware.OptionalAuthMiddleware(articleHandler.GetByID))
+	mux.HandleFunc("GET /api/articles/by-slug/{slug}", middleware.OptionalAuthMiddleware(articleHandler.GetBySlug))
 
 	// Protected routes
+	mux.HandleFunc("GET /api/articles/scheduled", middleware.AuthMiddleware(articleHandler.GetScheduled))
 	mux.HandleFunc("POST /api/articles", middleware.AuthMiddleware(articleHandler.Create))
 	mux.HandleFunc("PUT /api/articles/{id}", middleware.AuthMiddleware(articleHandler.Update))
+	mux.HandleFunc("PATCH /api/articles/{id}", middleware.AuthMiddleware(articleHandler.Patch))
 	mux.HandleFunc("DELETE /api/articles/{id}", middleware.AuthMiddleware(articleHandler.Delete))
+	mux.HandleFunc("POST /api/articles/{id}/submit", middleware.AuthMiddleware(articleHandler.Submit))
+	mux.HandleFunc("POST /api/articles/{id}/publish", middleware.AuthMiddleware(articleHandler.Publish))
+	mux.HandleFunc("POST /api/articles/{id}/unpublish", middleware.AuthMiddleware(articleHandler.Unpublish))
+	mux.HandleFunc("POST /api/articles/{id}/archive", middleware.AuthMiddleware(articleHandler.Archive))
+	// ServeMux refuses /api/articles/{id}/revisions next to
+	// /api/articles/by-slug/{slug}, so the list is registered under a
+	// wildcard. Other names are turned away before authentication so an
+	// unknown path is a 404 rather than a 401.
+	revisions := middleware.AuthMiddleware(articleHandler.GetRevisions)
+	mux.HandleFunc("GET /api/articles/{id}/{list}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("list") != "revisions" {
+			http.NotFound(w, r)
+			return
+		}
+		revisions(w, r)
+	})
+	mux.HandleFunc("GET /api/articles/{id}/revisions/diff", middleware.AuthMiddleware(articleHandler.DiffRevisions))
+	mux.HandleFunc("GET /api/articles/{id}/revisions/{rev}", middleware.AuthMiddleware(articleHandler.GetRevision))
+	mux.HandleFunc("POST /api/articles/{id}/revisions/{rev}/restore", middleware.AuthMiddleware(articleHandler.RestoreRevision))
+
+	searchHandler := handlers.NewSearchHandler(stores.NewSearchStore(db, d), authorStore)
+
+	// Public routes
+	mux.HandleFunc("GET /api/search", middleware.OptionalAuthMiddleware(searchHandler.Search))
+
+	tagStore := stores.NewTagStore(db, d)
+	tagHandler := handlers.NewTagHandler(tagStore, authorStore)
+
+	// Public routes
+	mux.HandleFunc("GET /api/tags", tagHandler.GetAll)
+	mux.HandleFunc("GET /api/tags/{slug}", tagHandler.GetBySlug)
+
+	// Protected routes
+	mux.HandleFunc("POST /api/tags", middleware.AuthMiddleware(tagHandler.Create))
+	mux.HandleFunc("PUT /api/tags/{slug}", middleware.AuthMiddleware(tagHandler.Update))
+	mux.HandleFunc("DELETE /api/tags/{slug}", middleware.AuthMiddleware(tagHandler.Delete))
+
+	categoryStore := stores.NewCategoryStore(db, d)
+	categoryHandler := handlers.NewCategoryHandler(categoryStore)
+
+	// Public routes
+	mux.HandleFunc("GET /api/categories", categoryHandler.GetAll)
+	mux.HandleFunc("GET /api/categories/{slug}", categoryHandler.GetBySlug)
+
+	// Protected routes
+	mux.HandleFunc("POST /api/categories", middleware.AuthMiddleware(categoryHandler.Create))
+	mux.HandleFunc("PUT /api/categories/{slug}", middleware.AuthMiddleware(categoryHandler.Update))
+	mux.HandleFunc("DELETE /api/categories/{slug}", middleware.AuthMiddleware(categoryHandler.Delete))
+
+	blog := site.New(siteConfig, theme, articleStore, authorStore, tagStore, stores.NewSitemapStore(db, d))
+	pageHandler := handlers.NewPageHandler(blog)
+
+	// Public pages
+	mux.HandleFunc("GET /{$}", pageHandler.Home)
+	mux.HandleFunc("GET /page/{n}", pageHandler.Home)
+	mux.HandleFunc("GET /articles/{slug}", pageHandler.Article)
+	mux.HandleFunc("GET /authors/{slug}", pageHandler.Author)
+	mux.HandleFunc("GET /authors/{slug}/page/{n}", pageHandler.Author)
+	mux.HandleFunc("GET /tags/{slug}", pageHandler.Tag)
+	mux.HandleFunc("GET /tags/{slug}/page/{n}", pageHandler.Tag)
+	mux.Handle("GET /static/", pageHandler.Static())
+	mux.HandleFunc("GET /sitemap.xml", pageHandler.Sitemap)
+	mux.HandleFunc("GET /sitemaps/{file}", pageHandler.Sitemap)
+	mux.HandleFunc("GET /robots.txt", pageHandler.Robots)
+
+	feedHandler := handlers.NewFeedHandler(blog)
+
+	// Public feeds
+	for file := range site.FeedFiles {
+		mux.HandleFunc("GET /"+file, feedHandler.Home)
+		mux.HandleFunc("GET /authors/{slug}/"+file, feedHandler.Author)
+		mux.HandleFunc("GET /tags/{slug}/"+file, feedHandler.Tag)
+	}
 
 	// Protected routes
 	mux.HandleFunc("GET /api/me", middleware.AuthMiddleware(authHandler.GetCurrentUser))
+	mux.HandleFunc("PATCH /api/users/{id}", middleware.AuthMiddleware(authHandler.PatchUser))
 
 	// Wrap the mux with CORS middleware
 	handler := middleware.CorsMiddleware(mux)
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"test-ai-api/imaging"
+	"test-ai-api/init/db"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/site"
+	"test-ai-api/storage"
+	"test-ai-api/stores"
+	"test-ai-api/types"
+	"test-ai-api/utils"
+	"testing"
+	"time"
+)
+
+func TestArticleRoutes(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test")
+
+	cfg := db.DefaultConfig()
+	cfg.DSN = filepath.Join(t.TempDir(), "test.db")
+	database, err := db.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	d := dialect.SQLite
+
+	blobs, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	theme, err := site.LoadTheme("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := SetupRoutes(database, d, blobs, imaging.Config{}, site.Config{}, theme)
+
+	roleID, err := d.InsertID(database, "INSERT INTO roles (name) VALUES (?)", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := d.InsertID(database, `
+		INSERT INTO users (first_name, last_name, email, password, is_admin, role_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		"Ada", "Admin", "admin@example.com", "x", true, roleID, time.Now(), time.Now(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	author, err := stores.NewAuthorStore(database, d).Create(types.AuthorCreate{FirstName: "Ada", LastName: "Admin"}, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := stores.NewArticleStore(database, d).Create(types.ArticleCreate{Title: "Routed", Content: "Body"}, author.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := utils.GenerateJWT(types.User{ID: userID, Email: "admin@example.com", IsAdmin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	articles := stores.NewArticleStore(database, d)
+	renamed, err := articles.Update(article.ID, article.Version, types.ArticleUpdate{Title: "Routed", Slug: "renamed", Content: "Body"}, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := strconv.FormatInt(article.ID, 10)
+	tests := []struct {
+		path     string
+		auth     bool
+		status   int
+		location string
+	}{
+		{"/api/articles/" + id, true, http.StatusOK, ""},
+		{"/api/articles/by-slug/" + renamed.Slug, true, http.StatusOK, ""},
+		{"/api/articles/by-slug/routed", true, http.StatusMovedPermanently, "/api/articles/by-slug/renamed"},
+		{"/api/articles/by-slug/missing", true, http.StatusNotFound, ""},
+		{"/api/articles/" + id + "/revisions", true, http.StatusOK, ""},
+		{"/api/articles/" + id + "/revisions", false, http.StatusUnauthorized, ""},
+		{"/api/articles/" + id + "/revisions/1", true, http.StatusOK, ""},
+		{"/api/articles/" + id + "/unknown", false, http.StatusNotFound, ""},
+		{"/api/articles/" + id + "/unknown", true, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.auth {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("GET %s (auth %v) = %d, want %d: %s", tt.path, tt.auth, rec.Code, tt.status, rec.Body)
+		}
+		if got := rec.Header().Get("Location"); got != tt.location {
+			t.Errorf("GET %s Location = %q, want %q", tt.path, got, tt.location)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"test-ai-api/stores"
+	"time"
+)
+
+// Scheduler periodically publishes articles whose scheduled time has come.
+// All state lives in the database, so a restart simply picks up anything
+// that became due while the process was down on its first tick.
+type Scheduler struct {
+	store    stores.ArticleRepository
+	interval time.Duration
+}
+
+func New(store stores.ArticleRepository, interval time.Duration) *Scheduler {
+	return &Scheduler{store: store, interval: interval}
+}
+
+// Run blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("Scheduler running every %s", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick() {
+	published, err := s.store.PublishDue(time.Now())
+	for _, article := range published {
+		log.Printf("Scheduler: published article %d %q (scheduled for %s)",
+			article.ID, article.Title, article.PublishedAt.Format(time.RFC3339))
+	}
+	if err != nil {
+		log.Printf("Scheduler: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package site
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	// Name is shown in page titles and as og:site_name.
+	Name string
+	// URL is the public origin used for canonical and OpenGraph URLs, such
+	// as https://blog.example.com. When empty, pages use the host of the
+	// request they answer and there is no sitemap.
+	URL string
+	// ThemeDir holds templates and static files that replace the built-in
+	// theme's. Files it does not have fall back to the built-in ones.
+	ThemeDir string
+	// PerPage is the number of articles on each listing page.
+	PerPage int
+	// FeedSize is the number of articles in each feed.
+	FeedSize int
+	// FeedFullContent puts whole articles in feeds instead of summaries.
+	// Readers can ask for the other mode with ?content=full or summary.
+	FeedFullContent bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Name:            "Blog",
+		PerPage:         10,
+		FeedSize:        20,
+		FeedFullContent: true,
+	}
+}
+
+// ConfigFromEnv builds a Config from SITE_* environment variables, falling
+// back to DefaultConfig for anything unset.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+
+	if name := os.Getenv("SITE_NAME"); name != "" {
+		cfg.Name = name
+	}
+	if url := os.Getenv("SITE_URL"); url != "" {
+		cfg.URL = strings.TrimSuffix(url, "/")
+	}
+	if dir := os.Getenv("SITE_THEME_DIR"); dir != "" {
+		cfg.ThemeDir = dir
+	}
+	if v, err := strconv.Atoi(os.Getenv("SITE_PER_PAGE")); err == nil && v > 0 {
+		cfg.PerPage = v
+	}
+
+	if v, err := strconv.Atoi(os.Getenv("SITE_FEED_SIZE")); err == nil && v > 0 {
+		cfg.FeedSize = v
+	}
+	switch os.Getenv("SITE_FEED_CONTENT") {
+	case "full":
+		cfg.FeedFullContent = true
+	case "summary":
+		cfg.FeedFullContent = false
+	}
+
+	return cfg
+}
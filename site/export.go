@@ -0,0 +1,400 @@
+package site
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"test-ai-api/feed"
+	"test-ai-api/types"
+
+	"golang.org/x/net/html"
+)
+
+// manifestName is the file in the output directory that records what the
+// last export wrote, so the next one can skip unchanged files and remove
+// stale ones.
+const manifestName = ".export-manifest.json"
+
+// exportBatch is how many articles or authors are read per query.
+const exportBatch = 100
+
+// ExportStats counts what an export did to the output directory.
+type ExportStats struct {
+	Written   int
+	Unchanged int
+	Removed   int
+	// MissingImages lists image paths referenced by articles that were
+	// not found in the image directory.
+	MissingImages []string
+}
+
+type manifest struct {
+	Files map[string]string `json:"files"`
+}
+
+type exporter struct {
+	site   *Site
+	dir    string
+	images fs.FS
+	old    map[string]string
+	files  map[string]string
+	copied map[string]bool
+	stats  ExportStats
+}
+
+// Export renders every public page into dir as a static site: each page
+// becomes an index.html in a directory named after its path, next to the
+// listings' feeds, the sitemap, robots.txt, the theme's static files and
+// the images articles refer to. Site-relative image URLs are read from
+// images; a nil images skips copying them.
+//
+// Files whose content is unchanged since the previous export into dir are
+// left alone, and files that export wrote but no longer produces are
+// removed. The configured site URL is required, since a static page has
+// no request to take its host from.
+func (s *Site) Export(dir string, images fs.FS) (ExportStats, error) {
+	if s.config.URL == "" {
+		return ExportStats{}, errors.New("export needs the site URL")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ExportStats{}, err
+	}
+
+	e := &exporter{
+		site:   s,
+		dir:    dir,
+		images: images,
+		old:    map[string]string{},
+		files:  map[string]string{},
+		copied: map[string]bool{},
+	}
+	if err := e.readManifest(); err != nil {
+		return e.stats, err
+	}
+
+	steps := []func() error{e.home, e.articles, e.authors, e.tags, e.notFound, e.sitemap, e.static}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return e.stats, err
+		}
+	}
+
+	if err := e.removeStale(); err != nil {
+		return e.stats, err
+	}
+	sort.Strings(e.stats.MissingImages)
+	return e.stats, e.writeManifest()
+}
+
+func (e *exporter) home() error {
+	if err := e.listing(e.site.Home); err != nil {
+		return err
+	}
+	return e.feeds("/", func(origin string, full bool) (feed.Feed, error) {
+		return e.site.HomeFeed(origin, full)
+	})
+}
+
+func (e *exporter) articles() error {
+	filter := types.ArticleFilter{
+		Status:  types.ArticleStatusPublished,
+		Sort:    types.ArticleSortNewest,
+		PerPage: exportBatch,
+	}
+	for filter.Page = 1; ; filter.Page++ {
+		result, err := e.site.articles.GetAll(filter)
+		if err != nil {
+			return err
+		}
+		for _, article := range result.Data {
+			if err := e.page(articlePage(article)); err != nil {
+				return err
+			}
+			if err := e.contentImages(article.ContentHTML); err != nil {
+				return err
+			}
+		}
+		if filter.Page*filter.PerPage >= result.Total {
+			return nil
+		}
+	}
+}
+
+func (e *exporter) authors() error {
+	for offset := 0; ; offset += exportBatch {
+		authors, err := e.site.authors.GetAll(exportBatch, offset)
+		if err != nil {
+			return err
+		}
+		for _, author := range authors {
+			err := e.listing(func(n int) (Page, error) {
+				return e.site.Author(author.Slug, n)
+			})
+			if err != nil {
+				return err
+			}
+			err = e.feeds(AuthorPath(author.Slug), func(origin string, full bool) (feed.Feed, error) {
+				return e.site.AuthorFeed(origin, author.Slug, full)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(authors) < exportBatch {
+			return nil
+		}
+	}
+}
+
+func (e *exporter) tags() error {
+	tags, err := e.site.tags.GetAll()
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		err := e.listing(func(n int) (Page, error) {
+			return e.site.Tag(tag.Slug, n)
+		})
+		if err != nil {
+			return err
+		}
+		err = e.feeds(TagPath(tag.Slug), func(origin string, full bool) (feed.Feed, error) {
+			return e.site.TagFeed(origin, tag.Slug, full)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notFound writes 404.html, which most static hosts serve for missing
+// paths.
+func (e *exporter) notFound() error {
+	var buf bytes.Buffer
+	if err := e.site.Render(&buf, e.site.config.URL, e.site.NotFound()); err != nil {
+		return err
+	}
+	return e.write("404.html", buf.Bytes())
+}
+
+// sitemap writes the sitemap, its parts if it is split, and robots.txt.
+func (e *exporter) sitemap() error {
+	files, err := e.site.SitemapFiles()
+	if err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := e.write(strings.TrimPrefix(name, "/"), data); err != nil {
+			return err
+		}
+	}
+
+	robots, err := e.site.Robots()
+	if err != nil {
+		return err
+	}
+	return e.write("robots.txt", robots)
+}
+
+func (e *exporter) static() error {
+	return fs.WalkDir(e.site.theme.Static, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(e.site.theme.Static, name)
+		if err != nil {
+			return err
+		}
+		return e.write(path.Join("static", name), data)
+	})
+}
+
+// listing writes every page of a paginated listing.
+func (e *exporter) listing(build func(n int) (Page, error)) error {
+	for n := 1; ; n++ {
+		page, err := build(n)
+		if err != nil {
+			return err
+		}
+		if err := e.page(page); err != nil {
+			return err
+		}
+		if page.NextPath == "" {
+			return nil
+		}
+	}
+}
+
+// feeds writes the listing's feed in every format, with the configured
+// content mode since a static host cannot honour ?content=.
+func (e *exporter) feeds(listing string, build func(origin string, full bool) (feed.Feed, error)) error {
+	f, err := build(e.site.config.URL, e.site.config.FeedFullContent)
+	if err != nil {
+		return err
+	}
+	for file, format := range FeedFiles {
+		data, err := feed.Encode(format, f, e.site.FeedURL(e.site.config.URL, listing, file))
+		if err != nil {
+			return err
+		}
+		if err := e.write(strings.TrimPrefix(FeedPath(listing, file), "/"), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) page(page Page) error {
+	var buf bytes.Buffer
+	if err := e.site.Render(&buf, e.site.config.URL, page); err != nil {
+		return fmt.Errorf("render %s: %w", page.Path, err)
+	}
+	return e.write(path.Join(strings.TrimPrefix(page.Path, "/"), "index.html"), buf.Bytes())
+}
+
+// contentImages copies the images an article's HTML points at on this
+// site. Images hosted elsewhere are left to their host.
+func (e *exporter) contentImages(content string) error {
+	if e.images == nil {
+		return nil
+	}
+
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if tok.Data != "img" {
+				continue
+			}
+			for _, attr := range tok.Attr {
+				if attr.Key == "src" {
+					if err := e.image(attr.Val); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+}
+
+func (e *exporter) image(src string) error {
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return nil
+	}
+	name := strings.TrimPrefix(path.Clean(u.Path), "/")
+	if e.copied[name] {
+		return nil
+	}
+	e.copied[name] = true
+
+	data, err := fs.ReadFile(e.images, name)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+		e.stats.MissingImages = append(e.stats.MissingImages, "/"+name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return e.write(name, data)
+}
+
+// write stores data at name under the output directory unless the last
+// export already wrote exactly this content there.
+func (e *exporter) write(name string, data []byte) error {
+	if !filepath.IsLocal(filepath.FromSlash(name)) || name == manifestName {
+		return fmt.Errorf("refusing to write %q outside the output directory", name)
+	}
+
+	if _, seen := e.files[name]; seen {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	e.files[name] = hash
+
+	target := filepath.Join(e.dir, filepath.FromSlash(name))
+	if e.old[name] == hash {
+		if _, err := os.Stat(target); err == nil {
+			e.stats.Unchanged++
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	// Write beside the target and rename so a half-written page is never
+	// served.
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return err
+	}
+	e.stats.Written++
+	return nil
+}
+
+// removeStale deletes files the previous export wrote that this one did
+// not, along with any directories that leaves empty.
+func (e *exporter) removeStale() error {
+	for name := range e.old {
+		if _, ok := e.files[name]; ok || !filepath.IsLocal(filepath.FromSlash(name)) {
+			continue
+		}
+		target := filepath.Join(e.dir, filepath.FromSlash(name))
+		if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		e.stats.Removed++
+
+		for d := filepath.Dir(target); d != filepath.Clean(e.dir); d = filepath.Dir(d) {
+			if os.Remove(d) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (e *exporter) readManifest() error {
+	data, err := os.ReadFile(filepath.Join(e.dir, manifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("%s: %w", manifestName, err)
+	}
+	if m.Files != nil {
+		e.old = m.Files
+	}
+	return nil
+}
+
+func (e *exporter) writeManifest() error {
+	data, err := json.MarshalIndent(manifest{Files: e.files}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(e.dir, manifestName), data, 0o644)
+}
@@ -0,0 +1,172 @@
+package site
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"test-ai-api/feed"
+	"test-ai-api/types"
+
+	"golang.org/x/net/html"
+)
+
+// FeedFiles maps the file name of each feed under a listing's path to its
+// format, e.g. /authors/jane/atom.xml.
+var FeedFiles = map[string]feed.Format{
+	"feed.xml":  feed.RSS,
+	"atom.xml":  feed.Atom,
+	"feed.json": feed.JSON,
+}
+
+// feedLinks are advertised in the head of every listing page, in this
+// order.
+var feedLinks = []struct {
+	file, mediaType, title string
+}{
+	{"feed.xml", "application/rss+xml", "RSS"},
+	{"atom.xml", "application/atom+xml", "Atom"},
+	{"feed.json", "application/feed+json", "JSON Feed"},
+}
+
+// FeedLink is a feed a page advertises with <link rel="alternate">.
+type FeedLink struct {
+	Title string
+	Type  string
+	Path  string
+}
+
+// FeedPath is the path of a feed file under the listing at base.
+func FeedPath(base, file string) string {
+	return path.Join(base, file)
+}
+
+func pageFeeds(base, title string) []FeedLink {
+	links := make([]FeedLink, len(feedLinks))
+	for i, l := range feedLinks {
+		links[i] = FeedLink{Title: title + " (" + l.title + ")", Type: l.mediaType, Path: FeedPath(base, l.file)}
+	}
+	return links
+}
+
+// HomeFeed is the feed of the newest published articles.
+func (s *Site) HomeFeed(baseURL string, full bool) (feed.Feed, error) {
+	return s.feed(baseURL, full, feed.Feed{
+		Title:       s.config.Name,
+		Description: "The latest articles from " + s.config.Name,
+	}, "/", types.ArticleFilter{})
+}
+
+// AuthorFeed is the feed of an author's published articles.
+func (s *Site) AuthorFeed(baseURL, slug string, full bool) (feed.Feed, error) {
+	author, err := s.authors.GetBySlug(slug)
+	if err != nil {
+		return feed.Feed{}, err
+	}
+	name := author.FirstName + " " + author.LastName
+	return s.feed(baseURL, full, feed.Feed{
+		Title:       name + " · " + s.config.Name,
+		Description: description(author.Bio, "Articles by "+name),
+	}, AuthorPath(author.Slug), types.ArticleFilter{AuthorSlug: author.Slug})
+}
+
+// TagFeed is the feed of the published articles carrying a tag.
+func (s *Site) TagFeed(baseURL, slug string, full bool) (feed.Feed, error) {
+	tag, err := s.tags.GetBySlug(slug)
+	if err != nil {
+		return feed.Feed{}, err
+	}
+	return s.feed(baseURL, full, feed.Feed{
+		Title:       tag.Name + " · " + s.config.Name,
+		Description: "Articles tagged " + tag.Name,
+	}, TagPath(tag.Slug), types.ArticleFilter{Tag: tag.Slug})
+}
+
+// FeedURL is the absolute URL of a feed file under the listing at base.
+func (s *Site) FeedURL(baseURL, base, file string) string {
+	return s.origin(baseURL) + FeedPath(base, file)
+}
+
+func (s *Site) feed(baseURL string, full bool, f feed.Feed, listing string, filter types.ArticleFilter) (feed.Feed, error) {
+	origin := s.origin(baseURL)
+
+	filter.Status = types.ArticleStatusPublished
+	filter.Sort = types.ArticleSortNewest
+	filter.Page = 1
+	filter.PerPage = s.config.FeedSize
+	result, err := s.articles.GetAll(filter)
+	if err != nil {
+		return feed.Feed{}, err
+	}
+
+	f.Link = origin + listing
+	f.Items = make([]feed.Item, len(result.Data))
+	for i, article := range result.Data {
+		item := feed.Item{
+			ID:        articleID(origin, article),
+			Title:     article.Title,
+			Link:      origin + ArticlePath(article.Slug),
+			Summary:   description(article.ShortDescription, article.Content),
+			Published: article.CreatedAt,
+			Updated:   article.UpdatedAt,
+		}
+		if article.PublishedAt != nil {
+			item.Published = *article.PublishedAt
+		}
+		if full {
+			item.Content = absoluteURLs(article.ContentHTML, origin)
+		}
+		if author := article.Author; author != nil && author.Slug != "" {
+			item.AuthorName = author.FirstName + " " + author.LastName
+			item.AuthorURL = origin + AuthorPath(author.Slug)
+		}
+		for _, tag := range article.Tags {
+			item.Categories = append(item.Categories, tag.Name)
+		}
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+		f.Items[i] = item
+	}
+	return f, nil
+}
+
+// articleID is a tag URI (RFC 4151) for the article, which stays the same
+// when its slug changes.
+func articleID(origin string, article types.Article) string {
+	host := origin
+	if u, err := url.Parse(origin); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:article/%d", host, article.CreatedAt.UTC().Format("2006-01-02"), article.ID)
+}
+
+// absoluteURLs rewrites site-relative href and src attributes to absolute
+// URLs, since feed readers show content away from the site.
+func absoluteURLs(content, origin string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return b.String()
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			b.Write(z.Raw())
+			continue
+		}
+		tok := z.Token()
+		rewritten := false
+		for i, attr := range tok.Attr {
+			if (attr.Key == "href" || attr.Key == "src") && strings.HasPrefix(attr.Val, "/") && !strings.HasPrefix(attr.Val, "//") {
+				tok.Attr[i].Val = origin + attr.Val
+				rewritten = true
+			}
+		}
+		if rewritten {
+			b.WriteString(tok.String())
+		} else {
+			b.Write(z.Raw())
+		}
+	}
+}
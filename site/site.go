@@ -0,0 +1,233 @@
+package site
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"test-ai-api/stores"
+	"test-ai-api/types"
+	"unicode/utf8"
+)
+
+// Site builds the public, server-rendered pages of the blog. Pages only
+// ever show what an anonymous reader may see.
+type Site struct {
+	config   Config
+	theme    *Theme
+	articles stores.ArticleRepository
+	authors  stores.AuthorRepository
+	tags     stores.TagRepository
+	sitemaps stores.SitemapRepository
+	sitemap  sitemapCache
+}
+
+func New(config Config, theme *Theme, articles stores.ArticleRepository, authors stores.AuthorRepository, tags stores.TagRepository, sitemaps stores.SitemapRepository) *Site {
+	return &Site{config: config, theme: theme, articles: articles, authors: authors, tags: tags, sitemaps: sitemaps}
+}
+
+func (s *Site) Config() Config {
+	return s.config
+}
+
+func (s *Site) Theme() *Theme {
+	return s.theme
+}
+
+// Page is everything a template needs to render one page. Paths are
+// relative to the site root; templates turn them into absolute URLs with
+// the url function.
+type Page struct {
+	Template    string
+	Title       string
+	Description string
+	// Path is the page's canonical path.
+	Path string
+	// Type is the og:type, such as "website" or "article".
+	Type string
+	// NoIndex asks search engines to leave the page out.
+	NoIndex bool
+
+	Article  *types.Article
+	Author   *types.Author
+	Tag      *types.Tag
+	Articles []types.Article
+
+	// PrevPath and NextPath link the pages of a listing.
+	PrevPath string
+	NextPath string
+	// Feeds are the feeds of the listing the page belongs to.
+	Feeds []FeedLink
+}
+
+// ArticlePath and the other path helpers define the site's URL layout.
+func ArticlePath(slug string) string { return "/articles/" + slug }
+func AuthorPath(slug string) string  { return "/authors/" + slug }
+func TagPath(slug string) string     { return "/tags/" + slug }
+
+// pagePath is the path of page n of the listing at base. The first page
+// lives at base itself.
+func pagePath(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + fmt.Sprintf("/page/%d", n)
+}
+
+// Home is page n of the newest published articles.
+func (s *Site) Home(n int) (Page, error) {
+	page := Page{
+		Template:    "home.html",
+		Title:       s.config.Name,
+		Description: "The latest articles from " + s.config.Name,
+		Type:        "website",
+	}
+	return page, s.list(&page, "/", n, types.ArticleFilter{})
+}
+
+// Article is the page for a published article. A slug the article has since
+// moved away from returns the article under its current slug, so callers
+// can compare page.Article.Slug and redirect.
+func (s *Site) Article(slug string) (Page, error) {
+	article, err := s.articles.GetBySlug(slug, types.Viewer{})
+	if err != nil {
+		return Page{}, err
+	}
+	return articlePage(article), nil
+}
+
+func articlePage(article types.Article) Page {
+	return Page{
+		Template:    "article.html",
+		Title:       article.Title,
+		Description: description(article.ShortDescription, article.Content),
+		Path:        ArticlePath(article.Slug),
+		Type:        "article",
+		Article:     &article,
+	}
+}
+
+// Author is page n of an author's published articles.
+func (s *Site) Author(slug string, n int) (Page, error) {
+	author, err := s.authors.GetBySlug(slug)
+	if err != nil {
+		return Page{}, err
+	}
+	name := author.FirstName + " " + author.LastName
+	page := Page{
+		Template:    "author.html",
+		Title:       name,
+		Description: description(author.Bio, "Articles by "+name),
+		Type:        "profile",
+		Author:      &author,
+	}
+	return page, s.list(&page, AuthorPath(author.Slug), n, types.ArticleFilter{AuthorSlug: author.Slug})
+}
+
+// Tag is page n of the published articles carrying a tag.
+func (s *Site) Tag(slug string, n int) (Page, error) {
+	tag, err := s.tags.GetBySlug(slug)
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{
+		Template:    "tag.html",
+		Title:       tag.Name,
+		Description: "Articles tagged " + tag.Name,
+		Type:        "website",
+		Tag:         &tag,
+	}
+	return page, s.list(&page, TagPath(tag.Slug), n, types.ArticleFilter{Tag: tag.Slug})
+}
+
+// NotFound is the page shown for anything that does not exist.
+func (s *Site) NotFound() Page {
+	return Page{
+		Template: "not_found.html",
+		Title:    "Page not found",
+		Type:     "website",
+		NoIndex:  true,
+	}
+}
+
+// list fills page with page n of the published articles matching filter.
+// Pages past the end are sql.ErrNoRows, except for an empty first page.
+func (s *Site) list(page *Page, base string, n int, filter types.ArticleFilter) error {
+	filter.Status = types.ArticleStatusPublished
+	filter.Sort = types.ArticleSortNewest
+	filter.Page = n
+	filter.PerPage = s.config.PerPage
+
+	result, err := s.articles.GetAll(filter)
+	if err != nil {
+		return err
+	}
+	if n > 1 && len(result.Data) == 0 {
+		return sql.ErrNoRows
+	}
+
+	page.Articles = result.Data
+	page.Path = pagePath(base, n)
+	page.Feeds = pageFeeds(base, page.Title)
+	if n > 1 {
+		page.PrevPath = pagePath(base, n-1)
+		page.Title = fmt.Sprintf("%s (page %d)", page.Title, n)
+	}
+	if n*s.config.PerPage < result.Total {
+		page.NextPath = pagePath(base, n+1)
+	}
+	return nil
+}
+
+// Render writes page using the theme. baseURL is the origin absolute URLs
+// are built from; the configured site URL wins when there is one.
+func (s *Site) Render(w io.Writer, baseURL string, page Page) error {
+	t, ok := s.theme.pages[page.Template]
+	if !ok {
+		return fmt.Errorf("theme has no template %q", page.Template)
+	}
+	baseURL = s.origin(baseURL)
+
+	return t.ExecuteTemplate(w, "layout", struct {
+		Page
+		SiteName  string
+		BaseURL   string
+		Canonical string
+	}{
+		Page:      page,
+		SiteName:  s.config.Name,
+		BaseURL:   baseURL,
+		Canonical: baseURL + page.Path,
+	})
+}
+
+// origin is the configured site URL, or baseURL when there is none.
+func (s *Site) origin(baseURL string) string {
+	if s.config.URL != "" {
+		return s.config.URL
+	}
+	return baseURL
+}
+
+// maxDescription is roughly what link previews show before truncating.
+const maxDescription = 200
+
+// description picks the first non-empty candidate and cuts it down to a
+// single line that fits a meta description.
+func description(candidates ...string) string {
+	for _, c := range candidates {
+		c = strings.Join(strings.Fields(c), " ")
+		if c == "" {
+			continue
+		}
+		if utf8.RuneCountInString(c) <= maxDescription {
+			return c
+		}
+		runes := []rune(c)[:maxDescription]
+		if i := strings.LastIndexByte(string(runes), ' '); i > 0 {
+			return string(runes)[:i] + "…"
+		}
+		return string(runes) + "…"
+	}
+	return ""
+}
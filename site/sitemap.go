@@ -0,0 +1,238 @@
+package site
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sitemapLimit is the most URLs the sitemap protocol allows in one file.
+// Bigger sites get a sitemap index pointing at numbered parts.
+const sitemapLimit = 50000
+
+// SitemapPath is where the sitemap, or the sitemap index, is served.
+const SitemapPath = "/sitemap.xml"
+
+// sitemapPartPath is the path of part n of a split sitemap.
+func sitemapPartPath(n int) string {
+	return fmt.Sprintf("/sitemaps/sitemap-%d.xml", n)
+}
+
+// sitemapCache holds the generated sitemap files until the content they
+// were built from changes.
+type sitemapCache struct {
+	mu          sync.Mutex
+	fingerprint string
+	files       map[string]sitemapFile
+}
+
+type sitemapFile struct {
+	data    []byte
+	updated time.Time
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// Sitemap returns the sitemap file at name, SitemapPath or one of the parts
+// it lists, along with the time of the newest change it covers. Files are
+// only rebuilt when the stores report that content changed. Unknown names
+// are sql.ErrNoRows, and so is every name when there is no configured site
+// URL: the sitemap only ever lists the canonical origin, never the host a
+// request claims to be for.
+func (s *Site) Sitemap(name string) ([]byte, time.Time, error) {
+	if s.config.URL == "" {
+		return nil, time.Time{}, sql.ErrNoRows
+	}
+	files, err := s.sitemapFiles()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	file, ok := files[name]
+	if !ok {
+		return nil, time.Time{}, sql.ErrNoRows
+	}
+	return file.data, file.updated, nil
+}
+
+func (s *Site) sitemapFiles() (map[string]sitemapFile, error) {
+	fingerprint, err := s.sitemaps.Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &s.sitemap
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.files != nil && c.fingerprint == fingerprint {
+		return c.files, nil
+	}
+
+	files, err := s.buildSitemap(s.config.URL)
+	if err != nil {
+		return nil, err
+	}
+	c.fingerprint, c.files = fingerprint, files
+	return files, nil
+}
+
+type sitemapEntry struct {
+	path    string
+	updated time.Time
+}
+
+func (s *Site) buildSitemap(origin string) (map[string]sitemapFile, error) {
+	content, err := s.sitemaps.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	// Listing pages change whenever one of their articles does.
+	var home time.Time
+	authors := map[string]time.Time{}
+	tags := map[string]time.Time{}
+	latest := func(m map[string]time.Time, key string, t time.Time) {
+		if t.After(m[key]) {
+			m[key] = t
+		}
+	}
+
+	entries := []sitemapEntry{{path: "/"}}
+	for _, article := range content.Articles {
+		entries = append(entries, sitemapEntry{ArticlePath(article.Slug), article.UpdatedAt})
+		if article.UpdatedAt.After(home) {
+			home = article.UpdatedAt
+		}
+		latest(authors, article.AuthorSlug, article.UpdatedAt)
+		for _, tag := range article.TagSlugs {
+			latest(tags, tag, article.UpdatedAt)
+		}
+	}
+	entries[0].updated = home
+
+	for _, author := range content.Authors {
+		latest(authors, author.Slug, author.UpdatedAt)
+		entries = append(entries, sitemapEntry{AuthorPath(author.Slug), authors[author.Slug]})
+	}
+
+	// Tags without published articles have nothing worth indexing.
+	tagSlugs := make([]string, 0, len(tags))
+	for slug := range tags {
+		tagSlugs = append(tagSlugs, slug)
+	}
+	sort.Strings(tagSlugs)
+	for _, slug := range tagSlugs {
+		entries = append(entries, sitemapEntry{TagPath(slug), tags[slug]})
+	}
+
+	files := map[string]sitemapFile{}
+	if len(entries) <= sitemapLimit {
+		file, err := encodeURLSet(origin, entries)
+		if err != nil {
+			return nil, err
+		}
+		files[SitemapPath] = file
+		return files, nil
+	}
+
+	var index sitemapIndex
+	var newest time.Time
+	for n := 1; len(entries) > 0; n++ {
+		part := entries[:min(sitemapLimit, len(entries))]
+		entries = entries[len(part):]
+
+		file, err := encodeURLSet(origin, part)
+		if err != nil {
+			return nil, err
+		}
+		files[sitemapPartPath(n)] = file
+		index.Sitemaps = append(index.Sitemaps, sitemapURL{Loc: origin + sitemapPartPath(n), LastMod: lastMod(file.updated)})
+		if file.updated.After(newest) {
+			newest = file.updated
+		}
+	}
+	data, err := encodeSitemapXML(index)
+	if err != nil {
+		return nil, err
+	}
+	files[SitemapPath] = sitemapFile{data: data, updated: newest}
+	return files, nil
+}
+
+func encodeURLSet(origin string, entries []sitemapEntry) (sitemapFile, error) {
+	set := urlSet{URLs: make([]sitemapURL, len(entries))}
+	var newest time.Time
+	for i, e := range entries {
+		set.URLs[i] = sitemapURL{Loc: origin + e.path, LastMod: lastMod(e.updated)}
+		if e.updated.After(newest) {
+			newest = e.updated
+		}
+	}
+	data, err := encodeSitemapXML(set)
+	return sitemapFile{data: data, updated: newest}, err
+}
+
+func encodeSitemapXML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Robots renders the theme's robots.txt, which is told where the sitemap
+// is. Without a configured site URL there is no sitemap, and SitemapURL is
+// empty.
+func (s *Site) Robots() ([]byte, error) {
+	data := struct {
+		BaseURL    string
+		SitemapURL string
+	}{BaseURL: s.config.URL}
+	if s.config.URL != "" {
+		data.SitemapURL = s.config.URL + SitemapPath
+	}
+
+	var buf bytes.Buffer
+	err := s.theme.robots.Execute(&buf, data)
+	return buf.Bytes(), err
+}
+
+// SitemapFiles returns every sitemap file by path, for the static export,
+// which requires the site URL.
+func (s *Site) SitemapFiles() (map[string][]byte, error) {
+	files, err := s.sitemapFiles()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(files))
+	for name, file := range files {
+		out[name] = file.data
+	}
+	return out, nil
+}
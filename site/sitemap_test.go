@@ -0,0 +1,91 @@
+package site
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"test-ai-api/types"
+	"testing"
+	"time"
+)
+
+// fakeSitemaps counts how often the sitemap content is built.
+type fakeSitemaps struct {
+	fingerprint string
+	builds      int
+}
+
+func (f *fakeSitemaps) Fingerprint() (string, error) {
+	return f.fingerprint, nil
+}
+
+func (f *fakeSitemaps) Content() (types.SitemapContent, error) {
+	f.builds++
+	return types.SitemapContent{
+		Articles: []types.SitemapArticle{{Slug: "hello", AuthorSlug: "jane", UpdatedAt: time.Now()}},
+	}, nil
+}
+
+func TestSitemapNeedsSiteURL(t *testing.T) {
+	sitemaps := &fakeSitemaps{fingerprint: "1"}
+	theme, err := LoadTheme("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(DefaultConfig(), theme, nil, nil, nil, sitemaps)
+
+	if _, _, err := s.Sitemap(SitemapPath); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Sitemap without SITE_URL = %v, want sql.ErrNoRows", err)
+	}
+	if sitemaps.builds != 0 {
+		t.Errorf("built %d times, want 0", sitemaps.builds)
+	}
+
+	robots, err := s.Robots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(robots), "Sitemap:") {
+		t.Errorf("robots.txt points at a sitemap without SITE_URL:\n%s", robots)
+	}
+}
+
+func TestSitemapUsesSiteURL(t *testing.T) {
+	sitemaps := &fakeSitemaps{fingerprint: "1"}
+	theme, err := LoadTheme("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.URL = "https://blog.example"
+	s := New(config, theme, nil, nil, nil, sitemaps)
+
+	for range 2 {
+		data, _, err := s.Sitemap(SitemapPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "<loc>https://blog.example/articles/hello</loc>") {
+			t.Errorf("sitemap does not use SITE_URL:\n%s", data)
+		}
+	}
+	if sitemaps.builds != 1 {
+		t.Errorf("built %d times, want 1", sitemaps.builds)
+	}
+
+	sitemaps.fingerprint = "2"
+	if _, _, err := s.Sitemap(SitemapPath); err != nil {
+		t.Fatal(err)
+	}
+	if sitemaps.builds != 2 {
+		t.Errorf("built %d times after the content changed, want 2", sitemaps.builds)
+	}
+
+	robots, err := s.Robots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(robots), "Sitemap: https://blog.example/sitemap.xml\n") {
+		t.Errorf("robots.txt does not point at the sitemap:\n%s", robots)
+	}
+}
@@ -0,0 +1,93 @@
+package site
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed themes/default
+var defaultTheme embed.FS
+
+// pageTemplates are the templates a theme renders pages with. Each one is
+// parsed together with layout.html, which defines the shared page shell.
+var pageTemplates = []string{"home.html", "article.html", "author.html", "tag.html", "not_found.html"}
+
+// Theme is a parsed set of page templates, the robots.txt template and the
+// static files the pages link to.
+type Theme struct {
+	pages  map[string]*template.Template
+	robots *texttemplate.Template
+	Static fs.FS
+}
+
+// LoadTheme parses the built-in theme, with any file found in dir taking
+// the place of the built-in one. An empty dir loads the built-in theme
+// unchanged.
+func LoadTheme(dir string) (*Theme, error) {
+	base, err := fs.Sub(defaultTheme, "themes/default")
+	if err != nil {
+		return nil, err
+	}
+	files := base
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("theme directory: %w", err)
+		}
+		files = overlayFS{upper: os.DirFS(dir), lower: base}
+	}
+
+	layout, err := template.New("layout.html").Funcs(funcs).ParseFS(files, "layout.html")
+	if err != nil {
+		return nil, err
+	}
+
+	theme := &Theme{pages: make(map[string]*template.Template, len(pageTemplates))}
+	for _, name := range pageTemplates {
+		t, err := layout.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if theme.pages[name], err = t.ParseFS(files, name); err != nil {
+			return nil, err
+		}
+	}
+	if theme.robots, err = texttemplate.ParseFS(files, "robots.txt"); err != nil {
+		return nil, err
+	}
+	if theme.Static, err = fs.Sub(files, "static"); err != nil {
+		return nil, err
+	}
+	return theme, nil
+}
+
+var funcs = template.FuncMap{
+	// html marks already-sanitized HTML, such as rendered article content,
+	// as safe to emit.
+	"html": func(s string) template.HTML { return template.HTML(s) },
+	"date": func(t time.Time) string { return t.Format("January 2, 2006") },
+	"iso":  func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+
+	"articlePath": ArticlePath,
+	"authorPath":  AuthorPath,
+	"tagPath":     TagPath,
+}
+
+// overlayFS serves files from upper, falling back to lower for anything
+// upper does not have.
+type overlayFS struct {
+	upper, lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.lower.Open(name)
+	}
+	return f, err
+}
@@ -0,0 +1,126 @@
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength caps generated slugs, including any -N collision suffix.
+const MaxLength = 80
+
+// substitutions covers letters that don't decompose into an ASCII base plus
+// combining marks, along with the Cyrillic and Greek alphabets.
+var substitutions = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe", 'ø': "o", 'Ø': "o",
+	'ł': "l", 'Ł': "l", 'đ': "d", 'Đ': "d", 'ð': "d", 'Ð': "d", 'þ': "th", 'Þ': "th",
+	'ı': "i", 'ħ': "h", 'Ħ': "h", 'ŋ': "ng", 'Ŋ': "ng",
+	'&': " and ", '@': " at ",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g",
+
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+}
+
+// Make turns arbitrary text into a lowercase ASCII slug: accents are
+// stripped, other scripts transliterated where we know how, punctuation
+// dropped and runs of separators collapsed into a single dash. It returns
+// an empty string when nothing usable is left.
+func Make(input string) string {
+	stripMarks := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	decomposed, _, err := transform.String(stripMarks, input)
+	if err != nil {
+		decomposed = input
+	}
+
+	var b strings.Builder
+	dash := false
+	write := func(s string) {
+		for _, r := range s {
+			switch {
+			case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+				if dash && b.Len() > 0 {
+					b.WriteByte('-')
+				}
+				dash = false
+				b.WriteRune(r)
+			case r == '\'' || r == '’':
+				// Apostrophes join words: "don't" -> "dont".
+			default:
+				dash = true
+			}
+		}
+	}
+
+	for _, r := range decomposed {
+		lower := unicode.ToLower(r)
+		if sub, ok := substitutions[lower]; ok {
+			write(sub)
+			continue
+		}
+		write(string(lower))
+	}
+
+	return truncate(b.String(), MaxLength)
+}
+
+// WithSuffix appends -n to base, trimming base so the result still fits in
+// MaxLength.
+func WithSuffix(base string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	return truncate(base, MaxLength-len(suffix)) + suffix
+}
+
+// maxSuffixDigits is how long a -N suffix Stems allows for.
+const maxSuffixDigits = 6
+
+// Stems returns the distinct prefixes WithSuffix puts before -N for
+// suffixes of up to six digits. A long base is trimmed further as N grows,
+// so base-2 and base-10 can start differently; looking up stem-N for each
+// stem finds every suffixed slug derived from base.
+func Stems(base string) []string {
+	var stems []string
+	for digits := 1; digits <= maxSuffixDigits; digits++ {
+		stem := truncate(base, MaxLength-digits-1)
+		if len(stems) == 0 || stems[len(stems)-1] != stem {
+			stems = append(stems, stem)
+		}
+	}
+	return stems
+}
+
+// Next picks base, or the first base-2, base-3, ... that isn't taken.
+func Next(base string, taken map[string]bool) string {
+	if !taken[base] {
+		return base
+	}
+	for n := 2; ; n++ {
+		candidate := WithSuffix(base, n)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// truncate cuts s to at most max bytes, preferring to break between words.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	if i := strings.LastIndexByte(s, '-'); i > max/2 {
+		s = s[:i]
+	}
+	return strings.Trim(s, "-")
+}
@@ -0,0 +1,75 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"Crème brûlée", "creme-brulee"},
+		{"Don't panic", "dont-panic"},
+		{"Straße & Co", "strasse-and-co"},
+		{"Привет мир", "privet-mir"},
+		{"  --  ", ""},
+	}
+	for _, tt := range tests {
+		if got := Make(tt.input); got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// longBase leaves room for -2 but not for -10, which drops its last word.
+var longBase = Make(strings.Repeat("abcdefg ", 8) + "abcdefghijklmn")
+
+func TestWithSuffixFits(t *testing.T) {
+	if len(longBase) != MaxLength-2 {
+		t.Fatalf("len(longBase) = %d, want %d", len(longBase), MaxLength-2)
+	}
+	for _, n := range []int{2, 9, 10, 99, 100, 123456} {
+		if s := WithSuffix(longBase, n); len(s) > MaxLength {
+			t.Errorf("WithSuffix(longBase, %d) = %q, %d bytes", n, s, len(s))
+		}
+	}
+}
+
+func TestStemsCoverSuffixes(t *testing.T) {
+	stems := Stems(longBase)
+	if len(stems) < 2 {
+		t.Fatalf("Stems(longBase) = %q, want a stem per trimming", stems)
+	}
+	for n := 2; n <= 100000; n++ {
+		s := WithSuffix(longBase, n)
+		covered := false
+		for _, stem := range stems {
+			if strings.HasPrefix(s, stem+"-") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			t.Fatalf("%q does not start with any of %q", s, stems)
+		}
+	}
+
+	if got := Stems("short"); len(got) != 1 || got[0] != "short" {
+		t.Errorf("Stems(short) = %q, want [short]", got)
+	}
+}
+
+func TestNextLongBase(t *testing.T) {
+	taken := map[string]bool{longBase: true}
+	seen := map[string]bool{}
+	for range 150 {
+		s := Next(longBase, taken)
+		if seen[s] || len(s) > MaxLength {
+			t.Fatalf("Next returned %q again or too long", s)
+		}
+		seen[s] = true
+		taken[s] = true
+	}
+}
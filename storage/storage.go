@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrNotFound is returned when no blob is stored under a key.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStorage keeps uploaded files under opaque, slash-separated keys.
+type BlobStorage interface {
+	// Put stores r under key, replacing anything already there. size is
+	// -1 when the length isn't known up front.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the blob stored under key, or ErrNotFound. The caller
+	// must close it.
+	Open(ctx context.Context, key string) (*Blob, error)
+	// Delete removes the blob under key. Deleting a missing blob is not
+	// an error.
+	Delete(ctx context.Context, key string) error
+	// DeleteAll removes every blob whose key starts with prefix, which
+	// must end in a slash.
+	DeleteAll(ctx context.Context, prefix string) error
+}
+
+// Blob is an open stored file.
+type Blob struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+type Config struct {
+	// Driver selects the backend: "local" (default) or "s3".
+	Driver string
+	// Dir is the root directory of the local backend.
+	Dir string
+	S3  S3Config
+}
+
+// S3Config points at an S3-compatible service such as AWS S3 or MinIO.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Driver: "local",
+		Dir:    "./uploads",
+		S3: S3Config{
+			Endpoint: "s3.amazonaws.com",
+			UseSSL:   true,
+		},
+	}
+}
+
+// ConfigFromEnv builds a Config from STORAGE_* and S3_* environment
+// variables, falling back to DefaultConfig for anything unset.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+
+	if driver := os.Getenv("STORAGE_DRIVER"); driver != "" {
+		cfg.Driver = driver
+	}
+	if dir := os.Getenv("STORAGE_DIR"); dir != "" {
+		cfg.Dir = dir
+	}
+	if endpoint := os.Getenv("S3_ENDPOINT"); endpoint != "" {
+		cfg.S3.Endpoint = endpoint
+	}
+	cfg.S3.Region = os.Getenv("S3_REGION")
+	cfg.S3.Bucket = os.Getenv("S3_BUCKET")
+	cfg.S3.AccessKeyID = os.Getenv("S3_ACCESS_KEY_ID")
+	cfg.S3.SecretAccessKey = os.Getenv("S3_SECRET_ACCESS_KEY")
+	if v, err := strconv.ParseBool(os.Getenv("S3_USE_SSL")); err == nil {
+		cfg.S3.UseSSL = v
+	}
+
+	return cfg
+}
+
+// Open returns the backend cfg selects, checking that it is usable.
+func Open(cfg Config) (BlobStorage, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocal(cfg.Dir)
+	case "s3":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return NewS3(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+var (
+	_ BlobStorage = (*Local)(nil)
+	_ BlobStorage = (*S3)(nil)
+)
@@ -2,32 +2,72 @@ package stores
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/markdown"
 	"test-ai-api/types"
-	"test-ai-api/utils"
 	"time"
 )
 
 type ArticleStore struct {
-	db *sql.DB
+	conn
 }
 
-func NewArticleStore(db *sql.DB) *ArticleStore {
-	return &ArticleStore{db: db}
+func NewArticleStore(db *sql.DB, d dialect.Dialect) *ArticleStore {
+	return &ArticleStore{conn: newConn(db, d)}
 }
 
 func (s *ArticleStore) Create(article types.ArticleCreate, authorID int64) (types.Article, error) {
-	slug := utils.GenerateSlug(article.Title)
-	result, err := s.db.Exec(`
-		INSERT INTO articles (title, slug, short_description, content, status, author_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		article.Title, slug, article.ShortDescription, article.Content,
-		article.Status, authorID, time.Now(), time.Now(),
-	)
-	if err != nil {
-		return types.Article{}, err
+	// New articles enter the lifecycle at the start; publishing goes
+	// through Transition so published_at is always stamped.
+	switch article.Status {
+	case "":
+		article.Status = types.ArticleStatusDraft
+	case types.ArticleStatusDraft, types.ArticleStatusInReview:
+	default:
+		return types.Article{}, &TransitionError{From: types.ArticleStatusDraft, To: article.Status}
 	}
 
-	id, err := result.LastInsertId()
+	var id int64
+	err := s.inTx(func(tx conn) error {
+		store := &ArticleStore{conn: tx}
+		tagIDs, err := tx.termIDs(tagTerm, article.Tags)
+		if err != nil {
+			return err
+		}
+		categoryIDs, err := tx.termIDs(categoryTerm, article.Categories)
+		if err != nil {
+			return err
+		}
+
+		id, err = tx.insertWithSlug(articleSlugScopes, article.Title, "article", func(tx conn, slug string) (int64, error) {
+			return tx.insert(`
+				INSERT INTO articles (title, slug, short_description, content, content_html, status, author_id, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				article.Title, slug, article.ShortDescription, article.Content, markdown.Render(article.Content),
+				article.Status, authorID, time.Now(), time.Now(),
+			)
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := tx.setTerms(tagTerm, id, tagIDs); err != nil {
+			return err
+		}
+		if err := tx.setTerms(categoryTerm, id, categoryIDs); err != nil {
+			return err
+		}
+
+		var userID int64
+		if err := tx.queryRow("SELECT user_id FROM authors WHERE id = ?", authorID).Scan(&userID); err != nil {
+			return err
+		}
+		return store.writeRevision(id, &userID)
+	})
 	if err != nil {
 		return types.Article{}, err
 	}
@@ -35,79 +75,527 @@ func (s *ArticleStore) Create(article types.ArticleCreate, authorID int64) (type
 	return s.GetByID(id)
 }
 
-func (s *ArticleStore) GetByID(id int64) (types.Article, error) {
+// articleSelect joins the author so every article is returned with it.
+var articleSelect = `
+		SELECT ` + articleColumns.list("a") + `, ` + authorColumns.list("au") + `
+		FROM articles a
+		LEFT JOIN authors au ON a.author_id = au.id`
+
+func scanArticle(row rowScanner) (types.Article, error) {
 	var article types.Article
 	var author types.Author
-	err := s.db.QueryRow(`
-		SELECT a.*, au.*
-		FROM articles a
-		LEFT JOIN authors au ON a.author_id = au.id
-		WHERE a.id = ? AND a.deleted_at IS NULL`,
-		id,
-	).Scan(
-		&article.ID, &article.Title, &article.Slug, &article.ShortDescription,
-		&article.Content, &article.Status, &article.AuthorID, &article.PublishedAt,
-		&article.CreatedAt, &article.UpdatedAt, &article.DeletedAt,
-		&author.ID, &author.FirstName, &author.LastName,
-		&author.Slug, &author.Bio, &author.UserID,
-		&author.CreatedAt, &author.UpdatedAt, &author.DeletedAt,
-	)
-	if err != nil {
+	dest := append(articleColumns.targets(&article), authorColumns.targets(&author)...)
+	if err := row.Scan(dest...); err != nil {
 		return types.Article{}, err
 	}
 	article.Author = &author
+	renderMissingHTML(&article)
 	return article, nil
 }
 
-func (s *ArticleStore) GetAll(limit int, offset int) ([]types.Article, error) {
-	rows, err := s.db.Query(`
-		SELECT a.*, 
-			   au.*
+// renderMissingHTML fills in ContentHTML for rows written before rendered
+// HTML was stored, such as seeded articles.
+func renderMissingHTML(article *types.Article) {
+	if article.ContentHTML == "" && article.Content != "" {
+		article.ContentHTML = markdown.Render(article.Content)
+	}
+}
+
+func (s *ArticleStore) GetByID(id int64) (types.Article, error) {
+	return s.withTerms(scanArticle(s.queryRow(articleSelect+`
+		WHERE a.id = ? AND a.deleted_at IS NULL`,
+		id,
+	)))
+}
+
+// articleSort describes how a listing is ordered and how the cursor value
+// is read from the last article of a page.
+type articleSort struct {
+	expr  string
+	desc  bool
+	value func(types.Article) any
+	parse func(string) (any, error)
+}
+
+var articleSorts = map[string]articleSort{
+	types.ArticleSortNewest: {expr: "COALESCE(a.published_at, a.created_at)", desc: true, value: articleDate, parse: parseCursorTime},
+	types.ArticleSortOldest: {expr: "COALESCE(a.published_at, a.created_at)", desc: false, value: articleDate, parse: parseCursorTime},
+	types.ArticleSortTitle:  {expr: "a.title", desc: false, value: func(a types.Article) any { return a.Title }, parse: func(v string) (any, error) { return v, nil }},
+}
+
+func articleDate(a types.Article) any {
+	if a.PublishedAt != nil {
+		return a.PublishedAt.Format(time.RFC3339Nano)
+	}
+	return a.CreatedAt.Format(time.RFC3339Nano)
+}
+
+func parseCursorTime(v string) (any, error) {
+	return time.Parse(time.RFC3339Nano, v)
+}
+
+// visibility restricts a query to the articles the viewer may read:
+// admins see everything, authors also see their own unpublished work and
+// everyone else only sees articles that are published and already live.
+func visibility(viewer types.Viewer) (string, []any) {
+	if viewer.IsAdmin {
+		return "", nil
+	}
+
+	live := "(a.status = ? AND a.published_at IS NOT NULL AND a.published_at <= ?)"
+	args := []any{types.ArticleStatusPublished, time.Now()}
+	if viewer.AuthorID != 0 {
+		return "(" + live + " OR a.author_id = ?)", append(args, viewer.AuthorID)
+	}
+	return live, args
+}
+
+// GetVisible returns the article only if the viewer is allowed to see it,
+// otherwise sql.ErrNoRows so hidden drafts are indistinguishable from
+// missing ones.
+func (s *ArticleStore) GetVisible(id int64, viewer types.Viewer) (types.Article, error) {
+	where := "a.id = ? AND a.deleted_at IS NULL"
+	args := []any{id}
+	if clause, clauseArgs := visibility(viewer); clause != "" {
+		where += " AND " + clause
+		args = append(args, clauseArgs...)
+	}
+
+	return s.withTerms(scanArticle(s.queryRow(articleSelect+`
+		WHERE `+where,
+		args...,
+	)))
+}
+
+func (s *ArticleStore) GetAll(filter types.ArticleFilter) (types.Page[types.Article], error) {
+	sort, ok := articleSorts[filter.Sort]
+	if !ok {
+		sort = articleSorts[types.ArticleSortNewest]
+		filter.Sort = types.ArticleSortNewest
+	}
+
+	where := []string{"a.deleted_at IS NULL"}
+	var args []any
+	if clause, clauseArgs := visibility(filter.Viewer); clause != "" {
+		where = append(where, clause)
+		args = append(args, clauseArgs...)
+	}
+	if filter.Status != "" {
+		where = append(where, "a.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.AuthorSlug != "" {
+		where = append(where, "au.slug = ?")
+		args = append(args, filter.AuthorSlug)
+	}
+	if filter.Tag != "" {
+		where = append(where, termFilter(tagTerm))
+		args = append(args, filter.Tag)
+	}
+	if filter.Category != "" {
+		where = append(where, termFilter(categoryTerm))
+		args = append(args, filter.Category)
+	}
+	if filter.PublishedFrom != nil {
+		where = append(where, "a.published_at >= ?")
+		args = append(args, *filter.PublishedFrom)
+	}
+	if filter.PublishedTo != nil {
+		where = append(where, "a.published_at <= ?")
+		args = append(args, *filter.PublishedTo)
+	}
+
+	page := types.Page[types.Article]{Data: []types.Article{}, PerPage: filter.PerPage}
+
+	err := s.queryRow(`
+		SELECT COUNT(*)
 		FROM articles a
 		LEFT JOIN authors au ON a.author_id = au.id
-		WHERE a.deleted_at IS NULL
+		WHERE `+strings.Join(where, " AND "),
+		args...,
+	).Scan(&page.Total)
+	if err != nil {
+		return page, err
+	}
+
+	// A backward cursor walks the listing in reverse from the first row of
+	// the page the client is on, and the rows are flipped back afterwards.
+	desc := sort.desc
+	var c cursor
+	offset := 0
+	if filter.Cursor != "" {
+		var err error
+		c, err = decodeCursor(filter.Cursor)
+		if err != nil || c.Sort != filter.Sort {
+			return page, ErrInvalidCursor
+		}
+		value, err := sort.parse(c.Value)
+		if err != nil {
+			return page, ErrInvalidCursor
+		}
+		if c.Back {
+			desc = !desc
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND a.id %s ?))", sort.expr, op, sort.expr, op))
+		args = append(args, value, value, c.ID)
+	} else {
+		page.Page = filter.Page
+		offset = (filter.Page - 1) * filter.PerPage
+	}
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	// Fetch one extra row to learn whether there is a page beyond this one.
+	rows, err := s.query(articleSelect+`
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+sort.expr+` `+direction+`, a.id `+direction+`
 		LIMIT ? OFFSET ?`,
-		limit, offset,
+		append(args, filter.PerPage+1, offset)...,
 	)
 	if err != nil {
-		return []types.Article{}, err
+		return page, err
 	}
 	defer rows.Close()
 
-	var articles []types.Article
-
 	for rows.Next() {
-		var article types.Article
-		var author types.Author
-		if err := rows.Scan(
-			&article.ID, &article.Title, &article.Slug, &article.ShortDescription,
-			&article.Content, &article.Status, &article.AuthorID,
-			&article.PublishedAt, &article.CreatedAt, &article.UpdatedAt,
-			&article.DeletedAt, &author.ID, &author.FirstName, &author.LastName,
-			&author.Slug, &author.Bio, &author.UserID,
-			&author.CreatedAt, &author.UpdatedAt, &author.DeletedAt,
-		); err != nil {
-			return []types.Article{}, err
-		}
-		article.Author = &author
-		articles = append(articles, article)
+		article, err := scanArticle(rows)
+		if err != nil {
+			return page, err
+		}
+		page.Data = append(page.Data, article)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
 	}
-	return articles, nil
+
+	// The extra row tells whether the listing goes on past the page in the
+	// direction it was read. A cursor page also has the rows it was reached
+	// from on its other side.
+	hasNext, hasPrev := len(page.Data) > filter.PerPage, filter.Cursor != ""
+	if hasNext {
+		page.Data = page.Data[:filter.PerPage]
+	}
+	if c.Back {
+		slices.Reverse(page.Data)
+		hasNext, hasPrev = hasPrev, hasNext
+	}
+	if len(page.Data) > 0 {
+		edge := func(a types.Article, back bool) string {
+			return encodeCursor(cursor{Value: fmt.Sprint(sort.value(a)), ID: a.ID, Sort: filter.Sort, Back: back})
+		}
+		if hasNext {
+			page.NextCursor = edge(page.Data[len(page.Data)-1], false)
+		}
+		if hasPrev {
+			page.PrevCursor = edge(page.Data[0], true)
+		}
+	}
+
+	if err := s.attachTerms(page.Data); err != nil {
+		return page, err
+	}
+
+	return page, nil
 }
 
-func (s *ArticleStore) Update(id int64, article types.ArticleUpdate) (types.Article, error) {
-	result, err := s.db.Exec(`
-		UPDATE articles SET 
-			title = ?, short_description = ?, content = ?, status = ?, published_at = ?, updated_at = ?
-		WHERE id = ? AND deleted_at IS NULL`,
-		article.Title, article.ShortDescription, article.Content,
-		article.Status, article.PublishedAt, time.Now(), id,
+// Update replaces the article's text, and its status or slug when they are
+// given. It is Patch with every text field supplied.
+func (s *ArticleStore) Update(id int64, version int64, article types.ArticleUpdate, editorID int64) (types.Article, error) {
+	patch := types.ArticlePatch{
+		Title:            &article.Title,
+		ShortDescription: &article.ShortDescription,
+		Content:          &article.Content,
+		PublishedAt:      article.PublishedAt,
+		Tags:             article.Tags,
+		Categories:       article.Categories,
+	}
+	if article.Slug != "" {
+		patch.Slug = &article.Slug
+	}
+	if article.Status != "" {
+		patch.Status = &article.Status
+	}
+	return s.Patch(id, version, patch, editorID)
+}
+
+// Patch writes the fields set in patch on top of the given version of the
+// row, leaving the others as they are. If the article has moved on since
+// that version was read, nothing is written and a *VersionConflictError
+// carries the version it is at now.
+func (s *ArticleStore) Patch(id int64, version int64, patch types.ArticlePatch, editorID int64) (types.Article, error) {
+	err := s.inTx(func(tx conn) error {
+		store := &ArticleStore{conn: tx}
+		current, err := store.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if current.Version != version {
+			return &VersionConflictError{Current: current.Version}
+		}
+
+		// Articles saved before revisions existed get their original text
+		// recorded first so the first edit can still be undone.
+		var revisions int
+		if err := tx.queryRow("SELECT COUNT(*) FROM article_revisions WHERE article_id = ?", id).Scan(&revisions); err != nil {
+			return err
+		}
+		if revisions == 0 {
+			if err := store.writeRevision(id, nil); err != nil {
+				return err
+			}
+		}
+
+		var set assignments
+		setIf(&set, "title", patch.Title)
+		setIf(&set, "short_description", patch.ShortDescription)
+		if patch.Content != nil {
+			set.add("content", *patch.Content)
+			set.add("content_html", markdown.Render(*patch.Content))
+		}
+
+		if patch.Status != nil && *patch.Status != current.Status {
+			publishedAt, err := nextPublishedAt(current, *patch.Status, patch.PublishedAt)
+			if err != nil {
+				return err
+			}
+			set.add("status", *patch.Status)
+			set.add("published_at", publishedAt)
+		} else if current.Status == types.ArticleStatusScheduled && patch.PublishedAt != nil {
+			// Moving the date of an already scheduled article.
+			publishedAt, err := nextPublishedAt(current, current.Status, patch.PublishedAt)
+			if err != nil {
+				return err
+			}
+			set.add("published_at", publishedAt)
+		}
+
+		slug, err := store.nextSlug(current, patch)
+		if err != nil {
+			return err
+		}
+		if slug != current.Slug {
+			set.add("slug", slug)
+		}
+
+		var tagIDs, categoryIDs []int64
+		if patch.Tags != nil {
+			if tagIDs, err = tx.termIDs(tagTerm, patch.Tags); err != nil {
+				return err
+			}
+		}
+		if patch.Categories != nil {
+			if categoryIDs, err = tx.termIDs(categoryTerm, patch.Categories); err != nil {
+				return err
+			}
+		}
+
+		if set.empty() && patch.Tags == nil && patch.Categories == nil {
+			return nil
+		}
+		set.add("updated_at", time.Now())
+		set.raw("version = version + 1")
+
+		result, err := tx.exec(`
+			UPDATE articles SET `+set.clause()+`
+			WHERE id = ? AND version = ? AND deleted_at IS NULL`,
+			append(set.args, id, version)...,
+		)
+		if err != nil {
+			return err
+		}
+		if err := requireRow(result); err != nil {
+			return tx.versionConflict("articles", id)
+		}
+
+		if slug != current.Slug {
+			if err := store.redirectSlug(id, current.Slug, slug); err != nil {
+				return err
+			}
+		}
+		if patch.Tags != nil {
+			if err := tx.setTerms(tagTerm, id, tagIDs); err != nil {
+				return err
+			}
+		}
+		if patch.Categories != nil {
+			if err := tx.setTerms(categoryTerm, id, categoryIDs); err != nil {
+				return err
+			}
+		}
+		return store.writeRevision(id, &editorID)
+	})
+	if err != nil {
+		return types.Article{}, err
+	}
+
+	return s.GetByID(id)
+}
+
+// writeRevision snapshots the article as it is now stored. editorID is nil
+// when the author of the change isn't known.
+func (s *ArticleStore) writeRevision(id int64, editorID *int64) error {
+	_, err := s.exec(`
+		INSERT INTO article_revisions (article_id, revision, title, slug, short_description, content, status, editor_id, created_at)
+		SELECT id,
+			(SELECT COALESCE(MAX(revision), 0) + 1 FROM article_revisions WHERE article_id = ?),
+			title, slug, short_description, content, status, ?, ?
+		FROM articles
+		WHERE id = ?`,
+		id, editorID, time.Now(), id,
+	)
+	return err
+}
+
+func (s *ArticleStore) GetRevisions(articleID int64) ([]types.ArticleRevision, error) {
+	rows, err := s.query(`
+		SELECT `+revisionColumns.list("")+`
+		FROM article_revisions
+		WHERE article_id = ?
+		ORDER BY revision DESC`,
+		articleID,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []types.ArticleRevision{}
+	for rows.Next() {
+		revision, err := revisionColumns.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (s *ArticleStore) GetRevision(articleID int64, revision int) (types.ArticleRevision, error) {
+	return revisionColumns.scan(s.queryRow(`
+		SELECT `+revisionColumns.list("")+`
+		FROM article_revisions
+		WHERE article_id = ? AND revision = ?`,
+		articleID, revision,
+	))
+}
+
+// RestoreRevision copies an old revision's text back onto the article.
+// The status is left alone, and the restore itself is recorded as a new
+// revision so it can be undone too.
+func (s *ArticleStore) RestoreRevision(articleID int64, version int64, revision int, editorID int64) (types.Article, error) {
+	rev, err := s.GetRevision(articleID, revision)
 	if err != nil {
 		return types.Article{}, err
 	}
 
-	id, err = result.LastInsertId()
+	update := types.ArticleUpdate{
+		Title:            rev.Title,
+		Slug:             rev.Slug,
+		ShortDescription: rev.ShortDescription,
+		Content:          rev.Content,
+	}
+	article, err := s.Update(articleID, version, update, editorID)
+	if errors.Is(err, ErrSlugTaken) {
+		// Another article has taken the old slug since; derive a new one.
+		update.Slug = ""
+		article, err = s.Update(articleID, version, update, editorID)
+	}
+	return article, err
+}
+
+// nextSlug picks the slug an update should leave the article with: the
+// client's explicit choice, a fresh one when the title changed, or the
+// current one.
+func (s *ArticleStore) nextSlug(current types.Article, patch types.ArticlePatch) (string, error) {
+	if patch.Slug != nil {
+		return s.claimSlug(articleSlugScopes, *patch.Slug, current.ID)
+	}
+	if patch.Title != nil && *patch.Title != current.Title {
+		return s.uniqueSlug(articleSlugScopes, *patch.Title, "article", current.ID)
+	}
+	return current.Slug, nil
+}
+
+// redirectSlug keeps the old slug resolving to the article after a rename.
+// If the article is taking back one of its own earlier slugs, that redirect
+// is dropped since the slug is canonical again.
+func (s *ArticleStore) redirectSlug(id int64, oldSlug, newSlug string) error {
+	if _, err := s.exec("DELETE FROM slug_redirects WHERE slug = ?", newSlug); err != nil {
+		return err
+	}
+	_, err := s.exec(`
+		INSERT INTO slug_redirects (slug, article_id, created_at)
+		VALUES (?, ?, ?)`,
+		oldSlug, id, time.Now(),
+	)
+	return err
+}
+
+// GetBySlug finds a visible article by its current slug, falling back to
+// the redirect history. Callers can compare the returned article's Slug
+// with the one they asked for to tell when the slug has moved.
+func (s *ArticleStore) GetBySlug(slug string, viewer types.Viewer) (types.Article, error) {
+	where := "a.slug = ? AND a.deleted_at IS NULL"
+	args := []any{slug}
+	if clause, clauseArgs := visibility(viewer); clause != "" {
+		where += " AND " + clause
+		args = append(args, clauseArgs...)
+	}
+
+	article, err := s.withTerms(scanArticle(s.queryRow(articleSelect+`
+		WHERE `+where,
+		args...,
+	)))
+	if err != sql.ErrNoRows {
+		return article, err
+	}
+
+	var id int64
+	if err := s.queryRow("SELECT article_id FROM slug_redirects WHERE slug = ?", slug).Scan(&id); err != nil {
+		return types.Article{}, err
+	}
+	return s.GetVisible(id, viewer)
+}
+
+// Transition moves an article to another status, enforcing the lifecycle in
+// types.CanTransitionArticle, and records a revision for editorID. The
+// UPDATE is guarded on the status that was read so two concurrent
+// transitions cannot both succeed.
+func (s *ArticleStore) Transition(id int64, to string, publishAt *time.Time, editorID int64) (types.Article, error) {
+	err := s.inTx(func(tx conn) error {
+		store := &ArticleStore{conn: tx}
+		current, err := store.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		if current.Status == to {
+			return &TransitionError{From: current.Status, To: to}
+		}
+		publishedAt, err := nextPublishedAt(current, to, publishAt)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.exec(`
+			UPDATE articles SET status = ?, published_at = ?, updated_at = ?, version = version + 1
+			WHERE id = ? AND status = ? AND deleted_at IS NULL`,
+			to, publishedAt, time.Now(), id, current.Status,
+		)
+		if err != nil {
+			return err
+		}
+		if err := requireRow(result); err != nil {
+			return &TransitionError{From: current.Status, To: to}
+		}
+		return store.writeRevision(id, &editorID)
+	})
 	if err != nil {
 		return types.Article{}, err
 	}
@@ -115,7 +603,149 @@ func (s *ArticleStore) Update(id int64, article types.ArticleUpdate) (types.Arti
 	return s.GetByID(id)
 }
 
+// GetScheduled lists the articles waiting to go live, soonest first. Authors
+// only see their own queue; admins see every author's.
+func (s *ArticleStore) GetScheduled(viewer types.Viewer) ([]types.Article, error) {
+	where := "a.status = ? AND a.deleted_at IS NULL"
+	args := []any{types.ArticleStatusScheduled}
+	if !viewer.IsAdmin {
+		where += " AND a.author_id = ?"
+		args = append(args, viewer.AuthorID)
+	}
+
+	rows, err := s.query(articleSelect+`
+		WHERE `+where+`
+		ORDER BY a.published_at ASC, a.id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	articles := []types.Article{}
+	for rows.Next() {
+		article, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, article)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return articles, s.attachTerms(articles)
+}
+
+// PublishDue promotes every scheduled article whose published_at has passed
+// and returns the ones it published. Each promotion is guarded on the
+// scheduled status, so running it from several processes at once is safe,
+// and records a revision with no editor.
+func (s *ArticleStore) PublishDue(now time.Time) ([]types.Article, error) {
+	rows, err := s.query(`
+		SELECT id FROM articles
+		WHERE status = ? AND published_at <= ? AND deleted_at IS NULL
+		ORDER BY published_at ASC`,
+		types.ArticleStatusScheduled, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var published []types.Article
+	for _, id := range ids {
+		promoted := false
+		err := s.inTx(func(tx conn) error {
+			result, err := tx.exec(`
+				UPDATE articles SET status = ?, updated_at = ?, version = version + 1
+				WHERE id = ? AND status = ? AND deleted_at IS NULL`,
+				types.ArticleStatusPublished, now, id, types.ArticleStatusScheduled,
+			)
+			if err != nil {
+				return err
+			}
+			if err := requireRow(result); err != nil {
+				// Someone else got there first.
+				return nil
+			}
+			promoted = true
+			store := &ArticleStore{conn: tx}
+			return store.writeRevision(id, nil)
+		})
+		if err != nil {
+			return published, err
+		}
+		if !promoted {
+			continue
+		}
+
+		article, err := s.GetByID(id)
+		if err != nil {
+			return published, err
+		}
+		published = append(published, article)
+	}
+
+	return published, nil
+}
+
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	if !types.IsArticleStatus(e.To) {
+		return fmt.Sprintf("unknown article status %q", e.To)
+	}
+	return fmt.Sprintf("cannot move article from %s to %s", e.From, e.To)
+}
+
+var ErrScheduleInPast = errors.New("scheduled articles need a published_at in the future")
+
+// nextPublishedAt validates the move from the article's current status to
+// the target one and returns the published_at the article should end up
+// with: stamped on publish, required and in the future when scheduling, and
+// cleared when work goes back to draft or review.
+func nextPublishedAt(current types.Article, to string, requested *time.Time) (*time.Time, error) {
+	if to != current.Status && !types.CanTransitionArticle(current.Status, to) {
+		return nil, &TransitionError{From: current.Status, To: to}
+	}
+
+	now := time.Now()
+	switch to {
+	case types.ArticleStatusPublished:
+		if requested != nil && !requested.After(now) {
+			return requested, nil
+		}
+		return &now, nil
+	case types.ArticleStatusScheduled:
+		if requested == nil || !requested.After(now) {
+			return nil, ErrScheduleInPast
+		}
+		return requested, nil
+	case types.ArticleStatusArchived:
+		return current.PublishedAt, nil
+	default:
+		return nil, nil
+	}
+}
+
 func (s *ArticleStore) Delete(id int64) error {
-	_, err := s.db.Exec("UPDATE articles SET deleted_at = ? WHERE id = ?", time.Now(), id)
+	_, err := s.exec("UPDATE articles SET deleted_at = ? WHERE id = ?", time.Now(), id)
 	return err
 }
@@ -0,0 +1,172 @@
+package stores
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"test-ai-api/types"
+)
+
+var (
+	ErrUnknownTag      = errors.New("unknown tag")
+	ErrUnknownCategory = errors.New("unknown category")
+)
+
+// articleTerm is a taxonomy linked to articles through a join table.
+// fallback is the slug given to a term whose name has no usable
+// characters.
+type articleTerm struct {
+	table    string
+	link     string
+	column   string
+	unknown  error
+	fallback string
+}
+
+var (
+	tagTerm      = articleTerm{table: "tags", link: "article_tags", column: "tag_id", unknown: ErrUnknownTag, fallback: "tag"}
+	categoryTerm = articleTerm{table: "categories", link: "article_categories", column: "category_id", unknown: ErrUnknownCategory, fallback: "category"}
+)
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// termIDs resolves slugs to ids, failing on the first one that doesn't
+// exist. Duplicates are dropped.
+func (c conn) termIDs(term articleTerm, slugs []string) ([]int64, error) {
+	if len(slugs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, len(slugs))
+	for i, s := range slugs {
+		args[i] = s
+	}
+	rows, err := c.query("SELECT id, slug FROM "+term.table+" WHERE slug IN ("+placeholders(len(slugs))+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := map[string]int64{}
+	for rows.Next() {
+		var id int64
+		var slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, err
+		}
+		found[slug] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	seen := map[int64]bool{}
+	for _, slug := range slugs {
+		id, ok := found[slug]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", term.unknown, slug)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// setTerms replaces the article's links in term with ids.
+func (c conn) setTerms(term articleTerm, articleID int64, ids []int64) error {
+	if _, err := c.exec("DELETE FROM "+term.link+" WHERE article_id = ?", articleID); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := c.exec("INSERT INTO "+term.link+" (article_id, "+term.column+") VALUES (?, ?)", articleID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTerms fetches the terms linked to each of the articles, keyed by
+// article id and sorted by name.
+func loadTerms[T any](c conn, cols columns[T], term articleTerm, articleIDs []any) (map[int64][]T, error) {
+	rows, err := c.query(`
+		SELECT l.article_id, `+cols.list("t")+`
+		FROM `+term.link+` l
+		INNER JOIN `+term.table+` t ON t.id = l.`+term.column+`
+		WHERE l.article_id IN (`+placeholders(len(articleIDs))+`)
+		ORDER BY t.name ASC, t.id ASC`,
+		articleIDs...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := map[int64][]T{}
+	for rows.Next() {
+		var articleID int64
+		var v T
+		if err := rows.Scan(append([]any{&articleID}, cols.targets(&v)...)...); err != nil {
+			return nil, err
+		}
+		terms[articleID] = append(terms[articleID], v)
+	}
+	return terms, rows.Err()
+}
+
+// attachTerms fills in the tags and categories of every article, with one
+// query per taxonomy.
+func (c conn) attachTerms(articles []types.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	ids := make([]any, len(articles))
+	for i, article := range articles {
+		ids[i] = article.ID
+	}
+
+	tags, err := loadTerms(c, tagColumns, tagTerm, ids)
+	if err != nil {
+		return err
+	}
+	categories, err := loadTerms(c, categoryColumns, categoryTerm, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range articles {
+		articles[i].Tags = tags[articles[i].ID]
+		if articles[i].Tags == nil {
+			articles[i].Tags = []types.Tag{}
+		}
+		articles[i].Categories = categories[articles[i].ID]
+		if articles[i].Categories == nil {
+			articles[i].Categories = []types.Category{}
+		}
+	}
+	return nil
+}
+
+// withTerms wraps a single-article lookup so it comes back with its tags
+// and categories.
+func (c conn) withTerms(article types.Article, err error) (types.Article, error) {
+	if err != nil {
+		return article, err
+	}
+	articles := []types.Article{article}
+	if err := c.attachTerms(articles); err != nil {
+		return types.Article{}, err
+	}
+	return articles[0], nil
+}
+
+// termFilter restricts a listing to articles linked to the term with slug.
+func termFilter(term articleTerm) string {
+	return "EXISTS (SELECT 1 FROM " + term.link + " l INNER JOIN " + term.table + " t ON t.id = l." + term.column +
+		" WHERE l.article_id = a.id AND t.slug = ?)"
+}
@@ -2,32 +2,28 @@ package stores
 
 import (
 	"database/sql"
+	"test-ai-api/init/db/dialect"
 	"test-ai-api/types"
-	"test-ai-api/utils"
 	"time"
 )
 
 type AuthorStore struct {
-	db *sql.DB
+	conn
 }
 
-func NewAuthorStore(db *sql.DB) *AuthorStore {
-	return &AuthorStore{db: db}
+func NewAuthorStore(db *sql.DB, d dialect.Dialect) *AuthorStore {
+	return &AuthorStore{conn: newConn(db, d)}
 }
 
 func (s *AuthorStore) Create(author types.AuthorCreate, userID int64) (types.Author, error) {
-	slug := utils.GenerateSlug(author.FirstName + " " + author.LastName)
-	result, err := s.db.Exec(`
-		INSERT INTO authors (first_name, last_name, bio, user_id, slug, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		author.FirstName, author.LastName, author.Bio, userID, slug,
-		time.Now(), time.Now(),
-	)
-	if err != nil {
-		return types.Author{}, err
-	}
-
-	id, err := result.LastInsertId()
+	id, err := s.insertWithSlug(authorSlugScopes, author.FirstName+" "+author.LastName, "author", func(tx conn, slug string) (int64, error) {
+		return tx.insert(`
+			INSERT INTO authors (first_name, last_name, bio, user_id, slug, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			author.FirstName, author.LastName, author.Bio, userID, slug,
+			time.Now(), time.Now(),
+		)
+	})
 	if err != nil {
 		return types.Author{}, err
 	}
@@ -36,45 +32,28 @@ func (s *AuthorStore) Create(author types.AuthorCreate, userID int64) (types.Aut
 }
 
 func (s *AuthorStore) GetByID(id int64) (types.Author, error) {
-	var author types.Author
-	err := s.db.QueryRow(`
-		SELECT *
+	return authorColumns.scan(s.queryRow(`
+		SELECT `+authorColumns.list("")+`
 		FROM authors
 		WHERE id = ? AND deleted_at IS NULL`,
 		id,
-	).Scan(
-		&author.ID, &author.FirstName, &author.LastName, &author.Slug,
-		&author.Bio, &author.UserID, &author.CreatedAt,
-		&author.UpdatedAt, &author.DeletedAt,
-	)
-	if err != nil {
-		return types.Author{}, err
-	}
-	return author, nil
+	))
 }
 
 func (s *AuthorStore) GetBySlug(slug string) (types.Author, error) {
-	var author types.Author
-	err := s.db.QueryRow(`
-		SELECT *
+	return authorColumns.scan(s.queryRow(`
+		SELECT `+authorColumns.list("")+`
 		FROM authors
 		WHERE slug = ? AND deleted_at IS NULL`,
 		slug,
-	).Scan(
-		&author.ID, &author.FirstName, &author.LastName, &author.Slug,
-		&author.Bio, &author.UserID, &author.CreatedAt,
-		&author.UpdatedAt, &author.DeletedAt,
-	)
-	if err != nil {
-		return types.Author{}, err
-	}
-	return author, nil
+	))
 }
 
 func (s *AuthorStore) GetAll(limit int, offset int) ([]types.Author, error) {
-	rows, err := s.db.Query(`
-		SELECT *
-		FROM authors a
+	rows, err := s.query(`
+		SELECT `+authorColumns.list("")+`
+		FROM authors
+		WHERE deleted_at IS NULL
 		LIMIT ? OFFSET ?`,
 		limit, offset,
 	)
@@ -85,57 +64,64 @@ func (s *AuthorStore) GetAll(limit int, offset int) ([]types.Author, error) {
 
 	var authors []types.Author
 	for rows.Next() {
-		var author types.Author
-		if err := rows.Scan(
-			&author.ID, &author.FirstName, &author.LastName, &author.Slug,
-			&author.Bio, &author.UserID, &author.CreatedAt,
-			&author.UpdatedAt, &author.DeletedAt,
-		); err != nil {
+		author, err := authorColumns.scan(rows)
+		if err != nil {
 			return nil, err
 		}
 		authors = append(authors, author)
 	}
-	return authors, nil
+	return authors, rows.Err()
 }
 
-func (s *AuthorStore) Update(id int64, author types.AuthorUpdate) (types.Author, error) {
-	result, err := s.db.Exec(`
-		UPDATE authors SET 
-			first_name = ?, last_name = ?, bio = ?, updated_at = ?
-		WHERE id = ? AND deleted_at IS NULL`,
-		author.FirstName, author.LastName, author.Bio, time.Now(), id,
+// Update writes author over the given version of the row, returning a
+// *VersionConflictError if it has changed since.
+func (s *AuthorStore) Update(id int64, version int64, author types.AuthorUpdate) (types.Author, error) {
+	return s.Patch(id, version, types.AuthorPatch{
+		FirstName: &author.FirstName,
+		LastName:  &author.LastName,
+		Bio:       &author.Bio,
+	})
+}
+
+// Patch writes only the fields set in patch, with the same version check
+// as Update.
+func (s *AuthorStore) Patch(id int64, version int64, patch types.AuthorPatch) (types.Author, error) {
+	var set assignments
+	setIf(&set, "first_name", patch.FirstName)
+	setIf(&set, "last_name", patch.LastName)
+	setIf(&set, "bio", patch.Bio)
+	if set.empty() {
+		return s.GetByID(id)
+	}
+	set.add("updated_at", time.Now())
+	set.raw("version = version + 1")
+
+	result, err := s.exec(`
+		UPDATE authors SET `+set.clause()+`
+		WHERE id = ? AND version = ? AND deleted_at IS NULL`,
+		append(set.args, id, version)...,
 	)
 	if err != nil {
 		return types.Author{}, err
 	}
 
-	id, err = result.LastInsertId()
-	if err != nil {
-		return types.Author{}, err
+	if err := requireRow(result); err != nil {
+		return types.Author{}, s.versionConflict("authors", id)
 	}
 
 	return s.GetByID(id)
 }
 
 func (s *AuthorStore) Delete(id int64) error {
-	_, err := s.db.Exec("UPDATE authors SET deleted_at = ? WHERE id = ?", time.Now(), id)
+	_, err := s.exec("UPDATE authors SET deleted_at = ? WHERE id = ?", time.Now(), id)
 	return err
 }
 
 func (s *AuthorStore) GetByUserID(userID int64) (types.Author, error) {
-	var author types.Author
-	err := s.db.QueryRow(`
-		SELECT id, first_name, last_name, slug, bio, user_id, created_at, updated_at, deleted_at
+	return authorColumns.scan(s.queryRow(`
+		SELECT `+authorColumns.list("")+`
 		FROM authors
 		WHERE user_id = ? AND deleted_at IS NULL`,
 		userID,
-	).Scan(
-		&author.ID, &author.FirstName, &author.LastName, &author.Slug,
-		&author.Bio, &author.UserID, &author.CreatedAt, &author.UpdatedAt,
-		&author.DeletedAt,
-	)
-	if err != nil {
-		return types.Author{}, err
-	}
-	return author, nil
+	))
 }
@@ -0,0 +1,28 @@
+package stores
+
+import (
+	"database/sql"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/types"
+)
+
+type CategoryStore struct {
+	termStore[types.Category]
+}
+
+func NewCategoryStore(db *sql.DB, d dialect.Dialect) *CategoryStore {
+	return &CategoryStore{termStore[types.Category]{
+		conn:     newConn(db, d),
+		term:     categoryTerm,
+		cols:     categoryColumns,
+		setCount: func(c *types.Category, n int) { c.ArticleCount = &n },
+	}}
+}
+
+func (s *CategoryStore) Create(category types.CategoryCreate) (types.Category, error) {
+	return s.create(category.Name, category.Slug, termValue{"description", category.Description})
+}
+
+func (s *CategoryStore) Update(id int64, category types.CategoryUpdate) (types.Category, error) {
+	return s.update(id, category.Name, category.Slug, termValue{"description", category.Description})
+}
@@ -0,0 +1,521 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"test-ai-api/types"
+	"testing"
+	"time"
+)
+
+// conformance is run against every dialect in testDialects, so each
+// repository behaves the same whichever database backs it.
+var conformance = []struct {
+	name string
+	run  func(t *testing.T, env *testEnv)
+}{
+	{"InsertID returns sequential ids", testInsertID},
+	{"users", testUsers},
+	{"authors", testAuthors},
+	{"articles", testArticles},
+	{"article paging", testArticlePaging},
+	{"article revisions", testArticleRevisions},
+	{"status change revisions", testStatusRevisions},
+	{"tags", testTags},
+	{"categories", testCategories},
+	{"images", testImages},
+	{"sitemap", testSitemap},
+	{"search", testSearch},
+}
+
+func TestConformance(t *testing.T) {
+	for _, tc := range conformance {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachDialect(t, tc.run)
+		})
+	}
+}
+
+func testInsertID(t *testing.T, env *testEnv) {
+	first, err := env.d.InsertID(env.db, "INSERT INTO roles (name) VALUES (?)", "editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := env.d.InsertID(env.db, "INSERT INTO roles (name) VALUES (?)", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first <= env.roleID || second != first+1 {
+		t.Errorf("ids = %d, %d after %d, want consecutive ids after it", first, second, env.roleID)
+	}
+
+	var name string
+	if err := env.db.QueryRow(env.d.Rebind("SELECT name FROM roles WHERE id = ?"), second).Scan(&name); err != nil || name != "admin" {
+		t.Errorf("row %d = %q, %v; want admin", second, name, err)
+	}
+}
+
+func testUsers(t *testing.T, env *testEnv) {
+	alice := env.user(t, "alice@example.com")
+	bob := env.user(t, "bob@example.com")
+
+	user, err := env.users.GetByID(alice)
+	if err != nil || user.Email != "alice@example.com" || user.Role.ID != env.roleID {
+		t.Fatalf("GetByID = %+v, %v", user, err)
+	}
+	if user, err := env.users.GetByEmail("bob@example.com"); err != nil || user.ID != bob {
+		t.Errorf("GetByEmail = %+v, %v; want id %d", user, err, bob)
+	}
+
+	_, err = env.d.InsertID(env.db, `
+		INSERT INTO users (first_name, last_name, email, password, role_id)
+		VALUES (?, ?, ?, ?, ?)`,
+		"Other", "Alice", "alice@example.com", "x", env.roleID,
+	)
+	if !env.d.IsUniqueViolation(err) {
+		t.Errorf("duplicate email insert: %v, want a unique violation", err)
+	}
+
+	name := "Alicia"
+	if user, err := env.users.Patch(alice, types.UserPatch{FirstName: &name}); err != nil || user.FirstName != name {
+		t.Errorf("Patch = %+v, %v", user, err)
+	}
+	taken := "bob@example.com"
+	if _, err := env.users.Patch(alice, types.UserPatch{Email: &taken}); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Patch to a taken email: %v, want ErrEmailTaken", err)
+	}
+	if _, err := env.users.Patch(999, types.UserPatch{FirstName: &name}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Patch missing user: %v, want sql.ErrNoRows", err)
+	}
+
+	page, err := env.users.GetAll(1, 1)
+	if err != nil || len(page) != 1 {
+		t.Fatalf("GetAll(1, 1) = %d users, %v", len(page), err)
+	}
+	if all, _ := env.users.GetAll(10, 0); len(all) != 2 {
+		t.Errorf("GetAll(10, 0) = %d users, want 2", len(all))
+	}
+
+	if err := env.users.Delete(int(bob)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.users.GetByID(bob); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByID after Delete: %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testAuthors(t *testing.T, env *testEnv) {
+	first := env.author(t, "Jane", "Doe")
+	second := env.author(t, "Jane", "Doe")
+	if first.ID == 0 || first.Slug != "jane-doe" || second.Slug != "jane-doe-2" || first.Version != 1 {
+		t.Fatalf("authors = %+v, %+v", first, second)
+	}
+
+	if got, err := env.authors.GetBySlug("jane-doe-2"); err != nil || got.ID != second.ID {
+		t.Errorf("GetBySlug = %+v, %v", got, err)
+	}
+	if got, err := env.authors.GetByUserID(first.UserID); err != nil || got.ID != first.ID {
+		t.Errorf("GetByUserID = %+v, %v", got, err)
+	}
+
+	updated, err := env.authors.Update(first.ID, first.Version, types.AuthorUpdate{FirstName: "Janet", LastName: "Doe", Bio: "Writes"})
+	if err != nil || updated.FirstName != "Janet" || updated.Bio != "Writes" || updated.Version != first.Version+1 {
+		t.Fatalf("Update = %+v, %v", updated, err)
+	}
+	var conflict *VersionConflictError
+	if _, err := env.authors.Update(first.ID, first.Version, types.AuthorUpdate{FirstName: "Stale"}); !errors.As(err, &conflict) || conflict.Current != updated.Version {
+		t.Errorf("Update with a stale version: %v, want conflict at %d", err, updated.Version)
+	}
+	bio := "Edits"
+	if patched, err := env.authors.Patch(first.ID, updated.Version, types.AuthorPatch{Bio: &bio}); err != nil || patched.Bio != bio || patched.FirstName != "Janet" {
+		t.Errorf("Patch = %+v, %v", patched, err)
+	}
+
+	if page, err := env.authors.GetAll(1, 1); err != nil || len(page) != 1 {
+		t.Errorf("GetAll(1, 1) = %d authors, %v", len(page), err)
+	}
+
+	if err := env.authors.Delete(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.authors.GetByID(second.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByID after Delete: %v, want sql.ErrNoRows", err)
+	}
+	// The deleted author still holds its slug.
+	if third := env.author(t, "Jane", "Doe"); third.Slug != "jane-doe-3" {
+		t.Errorf("slug after a deleted duplicate = %q, want jane-doe-3", third.Slug)
+	}
+}
+
+func testArticles(t *testing.T, env *testEnv) {
+	author := env.author(t, "Ann", "Writer")
+	tag, err := env.tags.Create(types.TagCreate{Name: "Go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	category, err := env.categories.Create(types.CategoryCreate{Name: "Guides"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := env.articles.Create(types.ArticleCreate{
+		Title:      "Hello, World",
+		Content:    "Some *markdown*",
+		Tags:       []string{tag.Slug},
+		Categories: []string{category.Slug},
+	}, author.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 || created.Slug != "hello-world" || created.Status != types.ArticleStatusDraft ||
+		created.Author == nil || created.Author.ID != author.ID || created.ContentHTML == "" ||
+		len(created.Tags) != 1 || len(created.Categories) != 1 {
+		t.Fatalf("Create = %+v", created)
+	}
+	if _, err := env.articles.Create(types.ArticleCreate{Title: "X", Tags: []string{"missing"}}, author.ID); !errors.Is(err, ErrUnknownTag) {
+		t.Errorf("Create with an unknown tag: %v, want ErrUnknownTag", err)
+	}
+
+	duplicate := env.article(t, author.ID, "Hello, World")
+	if duplicate.Slug != "hello-world-2" {
+		t.Errorf("duplicate title slug = %q, want hello-world-2", duplicate.Slug)
+	}
+	if _, err := env.articles.Update(duplicate.ID, duplicate.Version, types.ArticleUpdate{Title: "Hello", Slug: "hello-world"}, author.UserID); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("Update to a taken slug: %v, want ErrSlugTaken", err)
+	}
+
+	anonymous := types.Viewer{}
+	if _, err := env.articles.GetBySlug("hello-world", anonymous); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("draft visible to readers: %v", err)
+	}
+	if _, err := env.articles.GetBySlug("hello-world", types.Viewer{AuthorID: author.ID}); err != nil {
+		t.Errorf("draft hidden from its author: %v", err)
+	}
+
+	published := env.publish(t, created.ID)
+	if published.PublishedAt == nil {
+		t.Fatalf("published article has no published_at")
+	}
+	if got, err := env.articles.GetBySlug("hello-world", anonymous); err != nil || got.ID != created.ID {
+		t.Errorf("GetBySlug = %+v, %v", got, err)
+	}
+
+	title := "Hello again"
+	patched, err := env.articles.Patch(created.ID, published.Version, types.ArticlePatch{Title: &title}, author.UserID)
+	if err != nil || patched.Title != title || patched.Slug != "hello-again" {
+		t.Fatalf("Patch = %+v, %v", patched, err)
+	}
+	if got, err := env.articles.GetBySlug("hello-world", anonymous); err != nil || got.ID != created.ID {
+		t.Errorf("old slug does not redirect: %+v, %v", got, err)
+	}
+	var conflict *VersionConflictError
+	if _, err := env.articles.Patch(created.ID, published.Version, types.ArticlePatch{Title: &title}, author.UserID); !errors.As(err, &conflict) {
+		t.Errorf("Patch with a stale version: %v, want a version conflict", err)
+	}
+	var transition *TransitionError
+	if _, err := env.articles.Transition(duplicate.ID, types.ArticleStatusArchived, nil, author.UserID); !errors.As(err, &transition) {
+		t.Errorf("draft archived: %v, want a transition error", err)
+	}
+
+	if err := env.articles.Delete(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.articles.GetByID(created.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByID after Delete: %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testArticlePaging(t *testing.T, env *testEnv) {
+	author := env.author(t, "Page", "Turner")
+	for _, title := range []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"} {
+		env.article(t, author.ID, title)
+	}
+	admin := types.Viewer{IsAdmin: true}
+
+	first, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortTitle, Page: 1, PerPage: 2, Viewer: admin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Total != 5 || titles(first.Data) != "Alpha,Bravo" || first.NextCursor == "" {
+		t.Fatalf("page 1 = %s of %d, cursor %q", titles(first.Data), first.Total, first.NextCursor)
+	}
+	last, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortTitle, Page: 3, PerPage: 2, Viewer: admin})
+	if err != nil || titles(last.Data) != "Echo" || last.NextCursor != "" {
+		t.Errorf("page 3 = %s, cursor %q, %v", titles(last.Data), last.NextCursor, err)
+	}
+
+	next, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortTitle, Cursor: first.NextCursor, PerPage: 2, Viewer: admin})
+	if err != nil || titles(next.Data) != "Charlie,Delta" {
+		t.Errorf("after cursor = %s, %v", titles(next.Data), err)
+	}
+	if next.PrevCursor == "" || next.NextCursor == "" {
+		t.Fatalf("after cursor: prev %q, next %q; want both", next.PrevCursor, next.NextCursor)
+	}
+	end, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortTitle, Cursor: next.NextCursor, PerPage: 2, Viewer: admin})
+	if err != nil || titles(end.Data) != "Echo" || end.NextCursor != "" || end.PrevCursor == "" {
+		t.Fatalf("last cursor page = %s, next %q, %v", titles(end.Data), end.NextCursor, err)
+	}
+
+	// Walking back from the end visits the same pages in reverse.
+	back, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortTitle, Cursor: end.PrevCursor, PerPage: 2, Viewer: admin})
+	if err != nil || titles(back.Data) != "Charlie,Delta" || back.PrevCursor == "" || back.NextCursor == "" {
+		t.Fatalf("before cursor = %s, prev %q, next %q, %v", titles(back.Data), back.PrevCursor, back.NextCursor, err)
+	}
+	start, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortTitle, Cursor: back.PrevCursor, PerPage: 2, Viewer: admin})
+	if err != nil || titles(start.Data) != "Alpha,Bravo" || start.PrevCursor != "" || start.NextCursor == "" {
+		t.Errorf("first cursor page = %s, prev %q, %v", titles(start.Data), start.PrevCursor, err)
+	}
+	newest, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortNewest, Page: 1, PerPage: 3, Viewer: admin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	older, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortNewest, Cursor: newest.NextCursor, PerPage: 3, Viewer: admin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortNewest, Cursor: older.PrevCursor, PerPage: 3, Viewer: admin})
+	if err != nil || titles(newer.Data) != titles(newest.Data) {
+		t.Errorf("back from %s = %s, %v; want %s", titles(older.Data), titles(newer.Data), err, titles(newest.Data))
+	}
+
+	if _, err := env.articles.GetAll(types.ArticleFilter{Sort: types.ArticleSortNewest, Cursor: first.NextCursor, PerPage: 2, Viewer: admin}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor for another sort: %v, want ErrInvalidCursor", err)
+	}
+
+	readers, err := env.articles.GetAll(types.ArticleFilter{Page: 1, PerPage: 10})
+	if err != nil || readers.Total != 0 {
+		t.Errorf("drafts listed for readers: %d, %v", readers.Total, err)
+	}
+}
+
+func titles(articles []types.Article) string {
+	var s string
+	for i, article := range articles {
+		if i > 0 {
+			s += ","
+		}
+		s += article.Title
+	}
+	return s
+}
+
+func testArticleRevisions(t *testing.T, env *testEnv) {
+	author := env.author(t, "Rev", "Iser")
+	article := env.article(t, author.ID, "First draft")
+
+	content := "Second draft"
+	patched, err := env.articles.Patch(article.ID, article.Version, types.ArticlePatch{Content: &content}, author.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := env.articles.GetRevisions(article.ID)
+	if err != nil || len(revisions) != 2 {
+		t.Fatalf("GetRevisions = %d, %v; want 2", len(revisions), err)
+	}
+	first, err := env.articles.GetRevision(article.ID, 1)
+	if err != nil || first.Content != "Body of First draft" {
+		t.Fatalf("GetRevision(1) = %+v, %v", first, err)
+	}
+
+	restored, err := env.articles.RestoreRevision(article.ID, patched.Version, 1, author.UserID)
+	if err != nil || restored.Content != first.Content {
+		t.Errorf("RestoreRevision = %+v, %v", restored, err)
+	}
+	if _, err := env.articles.GetRevision(article.ID, 99); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetRevision(99): %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testStatusRevisions(t *testing.T, env *testEnv) {
+	author := env.author(t, "Sched", "Uler")
+	article := env.article(t, author.ID, "On a timer")
+
+	publishAt := time.Now().Add(time.Hour)
+	if _, err := env.articles.Transition(article.ID, types.ArticleStatusInReview, nil, author.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.articles.Transition(article.ID, types.ArticleStatusScheduled, &publishAt, author.UserID); err != nil {
+		t.Fatal(err)
+	}
+	published, err := env.articles.PublishDue(publishAt.Add(time.Minute))
+	if err != nil || len(published) != 1 {
+		t.Fatalf("PublishDue = %d, %v; want 1", len(published), err)
+	}
+
+	revisions, err := env.articles.GetRevisions(article.ID)
+	if err != nil || len(revisions) != 4 {
+		t.Fatalf("GetRevisions = %d, %v; want 4", len(revisions), err)
+	}
+	// Newest first: the scheduler's, with no editor, then the author's
+	// two transitions.
+	if revisions[0].Status != types.ArticleStatusPublished || revisions[0].EditorID != nil {
+		t.Errorf("scheduler revision = %s by %v, want published by nobody", revisions[0].Status, revisions[0].EditorID)
+	}
+	for i, status := range []string{types.ArticleStatusScheduled, types.ArticleStatusInReview} {
+		revision := revisions[i+1]
+		if revision.Status != status || revision.EditorID == nil || *revision.EditorID != author.UserID {
+			t.Errorf("revision %d = %s by %v, want %s by %d", revision.Revision, revision.Status, revision.EditorID, status, author.UserID)
+		}
+	}
+}
+
+func testTags(t *testing.T, env *testEnv) {
+	golang, err := env.tags.Create(types.TagCreate{Name: "Go"})
+	if err != nil || golang.ID == 0 || golang.Slug != "go" {
+		t.Fatalf("Create = %+v, %v", golang, err)
+	}
+	again, err := env.tags.Create(types.TagCreate{Name: "Go"})
+	if err != nil || again.Slug != "go-2" {
+		t.Errorf("Create with a duplicate name = %+v, %v", again, err)
+	}
+	if _, err := env.tags.Create(types.TagCreate{Name: "Golang", Slug: "go"}); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("Create with a taken slug: %v, want ErrSlugTaken", err)
+	}
+
+	if got, err := env.tags.GetBySlug("go-2"); err != nil || got.ID != again.ID || got.ArticleCount == nil {
+		t.Errorf("GetBySlug = %+v, %v", got, err)
+	}
+	updated, err := env.tags.Update(again.ID, types.TagUpdate{Name: "Golang", Slug: "golang"})
+	if err != nil || updated.Name != "Golang" || updated.Slug != "golang" {
+		t.Errorf("Update = %+v, %v", updated, err)
+	}
+	if _, err := env.tags.Update(again.ID, types.TagUpdate{Name: "Go", Slug: "go"}); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("Update to a taken slug: %v, want ErrSlugTaken", err)
+	}
+
+	all, err := env.tags.GetAll()
+	if err != nil || len(all) != 2 || all[0].Name != "Go" {
+		t.Errorf("GetAll = %+v, %v", all, err)
+	}
+
+	if err := env.tags.Delete(golang.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.tags.GetByID(golang.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByID after Delete: %v, want sql.ErrNoRows", err)
+	}
+	if err := env.tags.Delete(golang.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting twice: %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testCategories(t *testing.T, env *testEnv) {
+	guides, err := env.categories.Create(types.CategoryCreate{Name: "Guides", Description: "How to"})
+	if err != nil || guides.Slug != "guides" || guides.Description != "How to" {
+		t.Fatalf("Create = %+v, %v", guides, err)
+	}
+	if again, err := env.categories.Create(types.CategoryCreate{Name: "Guides"}); err != nil || again.Slug != "guides-2" {
+		t.Errorf("Create with a duplicate name = %+v, %v", again, err)
+	}
+	if _, err := env.categories.Create(types.CategoryCreate{Name: "More guides", Slug: "guides"}); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("Create with a taken slug: %v, want ErrSlugTaken", err)
+	}
+
+	updated, err := env.categories.Update(guides.ID, types.CategoryUpdate{Name: "Tutorials", Description: "Step by step"})
+	if err != nil || updated.Name != "Tutorials" || updated.Slug != "guides" || updated.Description != "Step by step" {
+		t.Errorf("Update = %+v, %v", updated, err)
+	}
+	if got, err := env.categories.GetBySlug("guides"); err != nil || got.ID != guides.ID {
+		t.Errorf("GetBySlug = %+v, %v", got, err)
+	}
+	if all, err := env.categories.GetAll(); err != nil || len(all) != 2 {
+		t.Errorf("GetAll = %d, %v", len(all), err)
+	}
+
+	if err := env.categories.Delete(guides.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.categories.GetByID(guides.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByID after Delete: %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testImages(t *testing.T, env *testEnv) {
+	ctx := context.Background()
+
+	linked, err := env.images.Create(types.ImageCreate{URL: "https://example.com/a.png"})
+	if err != nil || linked.ID == 0 || linked.URL != "https://example.com/a.png" {
+		t.Fatalf("Create = %+v, %v", linked, err)
+	}
+
+	data := []byte("not really a png, but the store does not care")
+	upload := types.ImageUpload{Filename: "Hero Image.png", Size: int64(len(data)), MimeType: "image/png", Width: 10, Height: 5}
+	first, err := env.images.CreateUpload(ctx, upload, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.URL != MediaPath(first) || first.StorageKey == "" || first.Width != 10 {
+		t.Fatalf("CreateUpload = %+v", first)
+	}
+	second, err := env.images.CreateUpload(ctx, upload, bytes.NewReader(data))
+	if err != nil || second.ID == first.ID || second.StorageKey != first.StorageKey {
+		t.Fatalf("identical upload = %+v, %v; want a new image sharing key %s", second, err, first.StorageKey)
+	}
+
+	if err := env.images.Delete(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.images.GetByID(first.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByID after Delete: %v, want sql.ErrNoRows", err)
+	}
+	blob, err := env.blobs.Open(ctx, second.StorageKey)
+	if err != nil {
+		t.Fatalf("blob removed while still referenced: %v", err)
+	}
+	blob.Close()
+
+	if err := env.images.Delete(ctx, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.blobs.Open(ctx, second.StorageKey); err == nil {
+		t.Errorf("blob kept after its last reference was deleted")
+	}
+}
+
+func testSitemap(t *testing.T, env *testEnv) {
+	before, err := env.sitemaps.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	author := env.author(t, "Site", "Mapper")
+	article := env.article(t, author.ID, "Mapped")
+	env.publish(t, article.ID)
+	env.article(t, author.ID, "Unpublished")
+
+	after, err := env.sitemaps.Fingerprint()
+	if err != nil || after == before {
+		t.Errorf("Fingerprint unchanged after publishing: %q, %v", after, err)
+	}
+	content, err := env.sitemaps.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content.Articles) != 1 || content.Articles[0].Slug != "mapped" || content.Articles[0].AuthorSlug != author.Slug {
+		t.Errorf("Content articles = %+v", content.Articles)
+	}
+}
+
+func testSearch(t *testing.T, env *testEnv) {
+	author := env.author(t, "Finn", "Seeker")
+	article := env.article(t, author.ID, "Searching for treasure")
+	env.publish(t, article.ID)
+	env.article(t, author.ID, "Hidden treasure draft")
+
+	page, err := env.search.Search(types.SearchQuery{Query: "treasure", Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Data[0].Article.ID != article.ID {
+		t.Fatalf("Search = %+v", page)
+	}
+	if want := "Searching for <mark>treasure</mark>"; page.Data[0].Title != want {
+		t.Errorf("title = %q, want %q", page.Data[0].Title, want)
+	}
+	if _, err := env.search.Search(types.SearchQuery{Query: "!!", Page: 1, PerPage: 10}); !errors.Is(err, ErrEmptySearch) {
+		t.Errorf("empty search: %v, want ErrEmptySearch", err)
+	}
+}
@@ -0,0 +1,119 @@
+package stores
+
+import (
+	"database/sql"
+	"strings"
+	"test-ai-api/init/db/dialect"
+)
+
+// conn wraps the database handle so every store writes queries with ?
+// placeholders and runs unchanged on both SQLite and Postgres.
+type conn struct {
+	db      dialect.Querier
+	pool    *sql.DB // nil while inside a transaction
+	dialect dialect.Dialect
+}
+
+func newConn(db *sql.DB, d dialect.Dialect) conn {
+	return conn{db: db, pool: db, dialect: d}
+}
+
+func (c conn) exec(query string, args ...any) (sql.Result, error) {
+	return c.db.Exec(c.dialect.Rebind(query), args...)
+}
+
+func (c conn) query(query string, args ...any) (*sql.Rows, error) {
+	return c.db.Query(c.dialect.Rebind(query), args...)
+}
+
+func (c conn) queryRow(query string, args ...any) *sql.Row {
+	return c.db.QueryRow(c.dialect.Rebind(query), args...)
+}
+
+func (c conn) insert(query string, args ...any) (int64, error) {
+	return c.dialect.InsertID(c.db, query, args...)
+}
+
+// inTx runs fn against a conn bound to a single transaction, committing if
+// fn succeeds. Nested calls reuse the outer transaction.
+func (c conn) inTx(fn func(tx conn) error) error {
+	if c.pool == nil {
+		return fn(c)
+	}
+
+	tx, err := c.pool.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(conn{db: tx, dialect: c.dialect}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// savepoint runs fn so that, inside a transaction, a failed statement in
+// it can be undone without aborting the whole transaction, which Postgres
+// otherwise does on any error.
+func (c conn) savepoint(name string, fn func() error) error {
+	if c.pool != nil {
+		return fn()
+	}
+
+	if _, err := c.exec("SAVEPOINT " + name); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		c.exec("ROLLBACK TO SAVEPOINT " + name)
+		c.exec("RELEASE SAVEPOINT " + name)
+		return err
+	}
+	_, err := c.exec("RELEASE SAVEPOINT " + name)
+	return err
+}
+
+// requireRow turns an UPDATE or DELETE that matched nothing into
+// sql.ErrNoRows so handlers can report a 404.
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// assignments builds the SET clause of an UPDATE that only touches the
+// columns a caller actually supplied.
+type assignments struct {
+	cols []string
+	args []any
+}
+
+func (a *assignments) add(col string, value any) {
+	a.cols = append(a.cols, col+" = ?")
+	a.args = append(a.args, value)
+}
+
+// raw adds an assignment that takes no argument, like "version = version + 1".
+func (a *assignments) raw(expr string) {
+	a.cols = append(a.cols, expr)
+}
+
+func (a *assignments) empty() bool {
+	return len(a.cols) == 0
+}
+
+func (a *assignments) clause() string {
+	return strings.Join(a.cols, ", ")
+}
+
+// setIf adds col = *value when value is non-nil.
+func setIf[T any](a *assignments, col string, value *T) {
+	if value != nil {
+		a.add(col, *value)
+	}
+}
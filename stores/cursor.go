@@ -0,0 +1,36 @@
+package stores
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor marks the edge of a page by its sort key and id: the last row
+// for the page after it or, with Back set, the first row for the page
+// before it. It is handed to clients as an opaque base64 token.
+type cursor struct {
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+	Sort  string `json:"s"`
+	Back  bool   `json:"b,omitempty"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(token string) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -1,21 +1,39 @@
 package stores
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"test-ai-api/imaging"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/slug"
+	"test-ai-api/storage"
 	"test-ai-api/types"
 	"time"
 )
 
+// ImageStore records images and owns the blobs of uploaded ones. Identical
+// uploads share one blob: image_blobs counts the images referring to each,
+// and a blob is only removed when the last of them is deleted.
 type ImageStore struct {
-	db *sql.DB
+	conn
+	blobs storage.BlobStorage
 }
 
-func NewImageStore(db *sql.DB) *ImageStore {
-	return &ImageStore{db: db}
+func NewImageStore(db *sql.DB, d dialect.Dialect, blobs storage.BlobStorage) *ImageStore {
+	return &ImageStore{conn: newConn(db, d), blobs: blobs}
 }
 
 func (s *ImageStore) Create(image types.ImageCreate) (types.Image, error) {
-	result, err := s.db.Exec(`
+	id, err := s.insert(`
 		INSERT INTO images (url, created_at)
 		VALUES (?, ?)`,
 		image.URL, time.Now(),
@@ -24,7 +42,27 @@ func (s *ImageStore) Create(image types.ImageCreate) (types.Image, error) {
 		return types.Image{}, err
 	}
 
-	id, err := result.LastInsertId()
+	return s.GetByID(id)
+}
+
+// CreateUpload stores an uploaded file and records it. Files are known by
+// their SHA-256, so bytes that are already stored gain a reference instead
+// of a second copy. body is read once to hash it and again to store it;
+// upload.Size is its length.
+func (s *ImageStore) CreateUpload(ctx context.Context, upload types.ImageUpload, body io.ReadSeeker) (types.Image, error) {
+	sum := sha256.New()
+	if _, err := io.Copy(sum, body); err != nil {
+		return types.Image{}, err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return types.Image{}, err
+	}
+	hash := hex.EncodeToString(sum.Sum(nil))
+
+	id, err := s.addReference(hash, upload)
+	if errors.Is(err, sql.ErrNoRows) {
+		id, err = s.createBlob(ctx, hash, upload, body)
+	}
 	if err != nil {
 		return types.Image{}, err
 	}
@@ -32,24 +70,186 @@ func (s *ImageStore) Create(image types.ImageCreate) (types.Image, error) {
 	return s.GetByID(id)
 }
 
+// addReference records upload against the stored blob with the given
+// hash, or returns sql.ErrNoRows if there is none.
+func (s *ImageStore) addReference(hash string, upload types.ImageUpload) (int64, error) {
+	var id int64
+	err := s.inTx(func(tx conn) error {
+		result, err := tx.exec("UPDATE image_blobs SET ref_count = ref_count + 1 WHERE sha256 = ?", hash)
+		if err != nil {
+			return err
+		}
+		if err := requireRow(result); err != nil {
+			return err
+		}
+
+		var key string
+		if err := tx.queryRow("SELECT storage_key FROM image_blobs WHERE sha256 = ?", hash).Scan(&key); err != nil {
+			return err
+		}
+		id, err = insertUpload(tx, key, upload)
+		return err
+	})
+	return id, err
+}
+
+// createBlob puts body in blob storage under a fresh key and records
+// upload as its first reference.
+//
+// Keys are random rather than derived from the hash, so a blob being
+// removed by its last delete can never be confused with a new upload of
+// the same bytes.
+func (s *ImageStore) createBlob(ctx context.Context, hash string, upload types.ImageUpload, body io.Reader) (int64, error) {
+	key, err := newBlobKey(upload.MimeType)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.blobs.Put(ctx, key, body, upload.Size, upload.MimeType); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = s.inTx(func(tx conn) error {
+		_, err := tx.exec(`
+			INSERT INTO image_blobs (storage_key, sha256, ref_count, created_at)
+			VALUES (?, ?, 1, ?)`,
+			key, hash, time.Now(),
+		)
+		if err != nil {
+			return err
+		}
+		id, err = insertUpload(tx, key, upload)
+		return err
+	})
+	if err != nil {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Printf("removing orphaned blob %s: %v", key, err)
+		}
+		// Someone else stored the same bytes first.
+		if id, refErr := s.addReference(hash, upload); refErr == nil {
+			return id, nil
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func insertUpload(tx conn, key string, upload types.ImageUpload) (int64, error) {
+	return tx.insert(`
+		INSERT INTO images (url, filename, storage_key, size, mime_type, width, height, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		"", upload.Filename, key, upload.Size, upload.MimeType, upload.Width, upload.Height, time.Now(),
+	)
+}
+
+// newBlobKey returns a fresh random key, grouped by month so local storage
+// directories stay a manageable size.
+func newBlobKey(mimeType string) (string, error) {
+	var ext string
+	for _, kind := range imaging.Formats {
+		if kind.MimeType == mimeType {
+			ext = kind.Ext
+		}
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "images/" + time.Now().UTC().Format("2006/01") + "/" + hex.EncodeToString(b) + ext, nil
+}
+
+// Uploaded images are given their media URL on the way out; only linked
+// images have one stored.
 func (s *ImageStore) GetByID(id int64) (types.Image, error) {
-	var image types.Image
-	err := s.db.QueryRow(`
-		SELECT id, url, created_at, deleted_at
+	image, err := imageColumns.scan(s.queryRow(`
+		SELECT `+imageColumns.list("")+`
 		FROM images
 		WHERE id = ? AND deleted_at IS NULL`,
 		id,
-	).Scan(&image.ID, &image.URL, &image.CreatedAt, &image.DeletedAt)
-	if err != nil {
-		return types.Image{}, err
+	))
+	if err == nil && image.StorageKey != "" {
+		image.URL = MediaPath(image)
 	}
-	return image, nil
+	return image, err
 }
 
-func (s *ImageStore) Delete(id int64) error {
-	_, err := s.db.Exec(
-		"UPDATE images SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL",
-		time.Now(), id,
-	)
-	return err
+// MediaPath is where an uploaded image is served. The file name only makes
+// links readable; the id alone identifies the image.
+func MediaPath(image types.Image) string {
+	return fmt.Sprintf("/media/%d/%s%s", image.ID, mediaName(image), path.Ext(image.StorageKey))
+}
+
+// VariantPath is where the copy of an uploaded image resized to width is
+// served.
+func VariantPath(image types.Image, width int) string {
+	ext, _ := imaging.VariantFormat(image.MimeType)
+	return fmt.Sprintf("/media/%d/%d/%s%s", image.ID, width, mediaName(image), ext)
+}
+
+// VariantKey is where the copy of an uploaded image resized to width is
+// kept in blob storage, next to the original.
+func VariantKey(image types.Image, width int) string {
+	ext, _ := imaging.VariantFormat(image.MimeType)
+	return fmt.Sprintf("%s%d%s", variantPrefix(image.StorageKey), width, ext)
+}
+
+// variantPrefix is the blob storage directory holding every resized copy
+// of the blob under key.
+func variantPrefix(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "/"
+}
+
+func mediaName(image types.Image) string {
+	name := slug.Make(strings.TrimSuffix(image.Filename, path.Ext(image.Filename)))
+	if name == "" {
+		return "image"
+	}
+	return name
+}
+
+// Delete soft-deletes an image. If it was the last reference to an
+// uploaded blob, the blob and its resized copies are removed too.
+func (s *ImageStore) Delete(ctx context.Context, id int64) error {
+	var orphan string
+	err := s.inTx(func(tx conn) error {
+		var key string
+		err := tx.queryRow("SELECT storage_key FROM images WHERE id = ? AND deleted_at IS NULL", id).Scan(&key)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.exec("UPDATE images SET deleted_at = ? WHERE id = ?", time.Now(), id); err != nil {
+			return err
+		}
+		if key == "" {
+			return nil
+		}
+
+		if _, err := tx.exec("UPDATE image_blobs SET ref_count = ref_count - 1 WHERE storage_key = ?", key); err != nil {
+			return err
+		}
+		result, err := tx.exec("DELETE FROM image_blobs WHERE storage_key = ? AND ref_count <= 0", key)
+		if err != nil {
+			return err
+		}
+		if requireRow(result) == nil {
+			orphan = key
+		}
+		return nil
+	})
+	if err != nil || orphan == "" {
+		return err
+	}
+
+	// The image is gone either way; a blob left behind is only wasted space.
+	if err := s.blobs.Delete(ctx, orphan); err != nil {
+		log.Printf("removing blob %s: %v", orphan, err)
+	}
+	if err := s.blobs.DeleteAll(ctx, variantPrefix(orphan)); err != nil {
+		log.Printf("removing variants of %s: %v", orphan, err)
+	}
+	return nil
 }
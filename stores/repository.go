@@ -0,0 +1,91 @@
+package stores
+
+import (
+	"context"
+	"io"
+	"test-ai-api/types"
+	"time"
+)
+
+// The handlers depend on these interfaces rather than the concrete stores so
+// the backing database can be swapped without touching the HTTP layer.
+
+type ArticleRepository interface {
+	Create(article types.ArticleCreate, authorID int64) (types.Article, error)
+	GetByID(id int64) (types.Article, error)
+	GetVisible(id int64, viewer types.Viewer) (types.Article, error)
+	GetBySlug(slug string, viewer types.Viewer) (types.Article, error)
+	GetAll(filter types.ArticleFilter) (types.Page[types.Article], error)
+	Update(id int64, version int64, article types.ArticleUpdate, editorID int64) (types.Article, error)
+	Patch(id int64, version int64, patch types.ArticlePatch, editorID int64) (types.Article, error)
+	GetRevisions(articleID int64) ([]types.ArticleRevision, error)
+	GetRevision(articleID int64, revision int) (types.ArticleRevision, error)
+	RestoreRevision(articleID int64, version int64, revision int, editorID int64) (types.Article, error)
+	Transition(id int64, to string, publishAt *time.Time, editorID int64) (types.Article, error)
+	GetScheduled(viewer types.Viewer) ([]types.Article, error)
+	PublishDue(now time.Time) ([]types.Article, error)
+	Delete(id int64) error
+}
+
+type AuthorRepository interface {
+	Create(author types.AuthorCreate, userID int64) (types.Author, error)
+	GetByID(id int64) (types.Author, error)
+	GetBySlug(slug string) (types.Author, error)
+	GetByUserID(userID int64) (types.Author, error)
+	GetAll(limit int, offset int) ([]types.Author, error)
+	Update(id int64, version int64, author types.AuthorUpdate) (types.Author, error)
+	Patch(id int64, version int64, patch types.AuthorPatch) (types.Author, error)
+	Delete(id int64) error
+}
+
+type ImageRepository interface {
+	Create(image types.ImageCreate) (types.Image, error)
+	CreateUpload(ctx context.Context, upload types.ImageUpload, body io.ReadSeeker) (types.Image, error)
+	GetByID(id int64) (types.Image, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type UserRepository interface {
+	GetAll(limit int, offset int) ([]types.User, error)
+	GetByEmail(email string) (types.User, error)
+	GetByID(id int64) (types.User, error)
+	Create(user types.User) (types.User, error)
+	Update(user types.User) (types.User, error)
+	Patch(id int64, patch types.UserPatch) (types.User, error)
+	Delete(id int) error
+	Register(ur types.UserRegister) (types.User, error)
+	Login(email, password string) (types.User, error)
+}
+
+type TagRepository interface {
+	Create(tag types.TagCreate) (types.Tag, error)
+	GetByID(id int64) (types.Tag, error)
+	GetBySlug(slug string) (types.Tag, error)
+	GetAll() ([]types.Tag, error)
+	Update(id int64, tag types.TagUpdate) (types.Tag, error)
+	Delete(id int64) error
+}
+
+type CategoryRepository interface {
+	Create(category types.CategoryCreate) (types.Category, error)
+	GetByID(id int64) (types.Category, error)
+	GetBySlug(slug string) (types.Category, error)
+	GetAll() ([]types.Category, error)
+	Update(id int64, category types.CategoryUpdate) (types.Category, error)
+	Delete(id int64) error
+}
+
+type SitemapRepository interface {
+	Fingerprint() (string, error)
+	Content() (types.SitemapContent, error)
+}
+
+var (
+	_ ArticleRepository  = (*ArticleStore)(nil)
+	_ AuthorRepository   = (*AuthorStore)(nil)
+	_ ImageRepository    = (*ImageStore)(nil)
+	_ UserRepository     = (*UserStore)(nil)
+	_ TagRepository      = (*TagStore)(nil)
+	_ CategoryRepository = (*CategoryStore)(nil)
+	_ SitemapRepository  = (*SitemapStore)(nil)
+)
@@ -0,0 +1,230 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"test-ai-api/types"
+	"time"
+)
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// column maps a named table column to the struct field it is scanned into.
+type column[T any] struct {
+	name  string
+	field func(*T) any
+}
+
+// columns is the single source of truth for which columns a store reads.
+// Queries select columns by name instead of *, so adding a column to a
+// table never shifts the scan targets.
+type columns[T any] struct {
+	table string
+	cols  []column[T]
+}
+
+// list renders the column names, qualified with alias when it is non-empty.
+func (c columns[T]) list(alias string) string {
+	names := make([]string, len(c.cols))
+	for i, col := range c.cols {
+		if alias != "" {
+			names[i] = alias + "." + col.name
+		} else {
+			names[i] = col.name
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c columns[T]) targets(v *T) []any {
+	dest := make([]any, len(c.cols))
+	for i, col := range c.cols {
+		dest[i] = col.field(v)
+	}
+	return dest
+}
+
+func (c columns[T]) scan(row rowScanner) (T, error) {
+	var v T
+	err := row.Scan(c.targets(&v)...)
+	return v, err
+}
+
+// nullString scans a nullable TEXT column into a plain string field.
+type nullString struct {
+	s *string
+}
+
+func (n nullString) Scan(value any) error {
+	var ns sql.NullString
+	if err := ns.Scan(value); err != nil {
+		return err
+	}
+	*n.s = ns.String
+	return nil
+}
+
+// nullTime scans a nullable timestamp into a plain time.Time field.
+type nullTime struct {
+	t *time.Time
+}
+
+func (n nullTime) Scan(value any) error {
+	var nt sql.NullTime
+	if err := nt.Scan(value); err != nil {
+		return err
+	}
+	*n.t = nt.Time
+	return nil
+}
+
+var articleColumns = columns[types.Article]{
+	table: "articles",
+	cols: []column[types.Article]{
+		{"id", func(a *types.Article) any { return &a.ID }},
+		{"title", func(a *types.Article) any { return &a.Title }},
+		{"slug", func(a *types.Article) any { return &a.Slug }},
+		{"short_description", func(a *types.Article) any { return nullString{&a.ShortDescription} }},
+		{"content", func(a *types.Article) any { return &a.Content }},
+		{"content_html", func(a *types.Article) any { return nullString{&a.ContentHTML} }},
+		{"status", func(a *types.Article) any { return &a.Status }},
+		{"author_id", func(a *types.Article) any { return &a.AuthorID }},
+		{"published_at", func(a *types.Article) any { return &a.PublishedAt }},
+		{"created_at", func(a *types.Article) any { return nullTime{&a.CreatedAt} }},
+		{"updated_at", func(a *types.Article) any { return nullTime{&a.UpdatedAt} }},
+		{"deleted_at", func(a *types.Article) any { return &a.DeletedAt }},
+		{"version", func(a *types.Article) any { return &a.Version }},
+	},
+}
+
+var authorColumns = columns[types.Author]{
+	table: "authors",
+	cols: []column[types.Author]{
+		{"id", func(a *types.Author) any { return &a.ID }},
+		{"first_name", func(a *types.Author) any { return &a.FirstName }},
+		{"last_name", func(a *types.Author) any { return &a.LastName }},
+		{"slug", func(a *types.Author) any { return &a.Slug }},
+		{"bio", func(a *types.Author) any { return nullString{&a.Bio} }},
+		{"user_id", func(a *types.Author) any { return &a.UserID }},
+		{"created_at", func(a *types.Author) any { return nullTime{&a.CreatedAt} }},
+		{"updated_at", func(a *types.Author) any { return nullTime{&a.UpdatedAt} }},
+		{"deleted_at", func(a *types.Author) any { return &a.DeletedAt }},
+		{"version", func(a *types.Author) any { return &a.Version }},
+	},
+}
+
+var userColumns = columns[types.User]{
+	table: "users",
+	cols: []column[types.User]{
+		{"id", func(u *types.User) any { return &u.ID }},
+		{"first_name", func(u *types.User) any { return &u.FirstName }},
+		{"last_name", func(u *types.User) any { return &u.LastName }},
+		{"email", func(u *types.User) any { return &u.Email }},
+		{"password", func(u *types.User) any { return &u.Password }},
+		{"is_admin", func(u *types.User) any { return &u.IsAdmin }},
+		{"role_id", func(u *types.User) any { return &u.RoleID }},
+		{"last_login", func(u *types.User) any { return &u.LastLogin }},
+		{"created_at", func(u *types.User) any { return nullTime{&u.CreatedAt} }},
+		{"updated_at", func(u *types.User) any { return nullTime{&u.UpdatedAt} }},
+		{"deleted_at", func(u *types.User) any { return &u.DeletedAt }},
+	},
+}
+
+var roleColumns = columns[types.Role]{
+	table: "roles",
+	cols: []column[types.Role]{
+		{"id", func(r *types.Role) any { return &r.ID }},
+		{"name", func(r *types.Role) any { return &r.Name }},
+	},
+}
+
+var imageColumns = columns[types.Image]{
+	table: "images",
+	cols: []column[types.Image]{
+		{"id", func(i *types.Image) any { return &i.ID }},
+		{"url", func(i *types.Image) any { return &i.URL }},
+		{"filename", func(i *types.Image) any { return &i.Filename }},
+		{"storage_key", func(i *types.Image) any { return &i.StorageKey }},
+		{"size", func(i *types.Image) any { return &i.Size }},
+		{"mime_type", func(i *types.Image) any { return &i.MimeType }},
+		{"width", func(i *types.Image) any { return &i.Width }},
+		{"height", func(i *types.Image) any { return &i.Height }},
+		{"created_at", func(i *types.Image) any { return nullTime{&i.CreatedAt} }},
+		{"deleted_at", func(i *types.Image) any { return &i.DeletedAt }},
+	},
+}
+
+var tagColumns = columns[types.Tag]{
+	table: "tags",
+	cols: []column[types.Tag]{
+		{"id", func(t *types.Tag) any { return &t.ID }},
+		{"name", func(t *types.Tag) any { return &t.Name }},
+		{"slug", func(t *types.Tag) any { return &t.Slug }},
+		{"created_at", func(t *types.Tag) any { return nullTime{&t.CreatedAt} }},
+		{"updated_at", func(t *types.Tag) any { return nullTime{&t.UpdatedAt} }},
+	},
+}
+
+var categoryColumns = columns[types.Category]{
+	table: "categories",
+	cols: []column[types.Category]{
+		{"id", func(c *types.Category) any { return &c.ID }},
+		{"name", func(c *types.Category) any { return &c.Name }},
+		{"slug", func(c *types.Category) any { return &c.Slug }},
+		{"description", func(c *types.Category) any { return nullString{&c.Description} }},
+		{"created_at", func(c *types.Category) any { return nullTime{&c.CreatedAt} }},
+		{"updated_at", func(c *types.Category) any { return nullTime{&c.UpdatedAt} }},
+	},
+}
+
+var revisionColumns = columns[types.ArticleRevision]{
+	table: "article_revisions",
+	cols: []column[types.ArticleRevision]{
+		{"id", func(r *types.ArticleRevision) any { return &r.ID }},
+		{"article_id", func(r *types.ArticleRevision) any { return &r.ArticleID }},
+		{"revision", func(r *types.ArticleRevision) any { return &r.Revision }},
+		{"title", func(r *types.ArticleRevision) any { return &r.Title }},
+		{"slug", func(r *types.ArticleRevision) any { return &r.Slug }},
+		{"short_description", func(r *types.ArticleRevision) any { return nullString{&r.ShortDescription} }},
+		{"content", func(r *types.ArticleRevision) any { return &r.Content }},
+		{"status", func(r *types.ArticleRevision) any { return &r.Status }},
+		{"editor_id", func(r *types.ArticleRevision) any { return &r.EditorID }},
+		{"created_at", func(r *types.ArticleRevision) any { return nullTime{&r.CreatedAt} }},
+	},
+}
+
+// checkColumns selects every mapped column from its table so a mapping that
+// drifts from the schema fails loudly at startup instead of mid-request.
+func checkColumns[T any](db *sql.DB, c columns[T]) error {
+	rows, err := db.Query("SELECT " + c.list("") + " FROM " + c.table + " LIMIT 0")
+	if err != nil {
+		return fmt.Errorf("%s columns do not match schema: %w", c.table, err)
+	}
+	return rows.Close()
+}
+
+// VerifySchema runs checkColumns for every mapping. The mappings are tested
+// against freshly migrated databases, so this is only a backstop for a
+// database whose schema was changed by hand.
+func VerifySchema(db *sql.DB) error {
+	checks := []func(*sql.DB) error{
+		func(db *sql.DB) error { return checkColumns(db, articleColumns) },
+		func(db *sql.DB) error { return checkColumns(db, authorColumns) },
+		func(db *sql.DB) error { return checkColumns(db, userColumns) },
+		func(db *sql.DB) error { return checkColumns(db, roleColumns) },
+		func(db *sql.DB) error { return checkColumns(db, imageColumns) },
+		func(db *sql.DB) error { return checkColumns(db, revisionColumns) },
+		func(db *sql.DB) error { return checkColumns(db, tagColumns) },
+		func(db *sql.DB) error { return checkColumns(db, categoryColumns) },
+	}
+	for _, check := range checks {
+		if err := check(db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
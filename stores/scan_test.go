@@ -0,0 +1,156 @@
+package stores
+
+import (
+	"reflect"
+	"strings"
+	"test-ai-api/types"
+	"testing"
+	"time"
+)
+
+// TestColumnMappings checks every columns mapping against a freshly
+// migrated schema: each mapped column must exist, and a row written
+// through the mapping must scan back unchanged.
+func TestColumnMappings(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, env *testEnv) {
+		if err := VerifySchema(env.db); err != nil {
+			t.Fatal(err)
+		}
+
+		at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+		later := at.Add(time.Hour)
+		author := env.author(t, "Column", "Mapper")
+		article := env.article(t, author.ID, "Mapped columns")
+		userID := env.user(t, "columns@example.com")
+
+		mappings := []struct {
+			name string
+			run  func(t *testing.T)
+		}{
+			{"articles", func(t *testing.T) {
+				roundTrip(t, env, articleColumns, types.Article{
+					ID: 1000, Title: "Title", Slug: "round-trip", ShortDescription: "Short",
+					Content: "Body", ContentHTML: "<p>Body</p>", Status: types.ArticleStatusPublished,
+					AuthorID: author.ID, PublishedAt: &at, CreatedAt: at, UpdatedAt: later,
+					DeletedAt: &later, Version: 3,
+				})
+			}},
+			{"authors", func(t *testing.T) {
+				roundTrip(t, env, authorColumns, types.Author{
+					ID: 1000, FirstName: "First", LastName: "Last", Slug: "round-trip", Bio: "Bio",
+					UserID: userID, CreatedAt: at, UpdatedAt: later, DeletedAt: &later, Version: 2,
+				})
+			}},
+			{"users", func(t *testing.T) {
+				roundTrip(t, env, userColumns, types.User{
+					ID: 1000, FirstName: "First", LastName: "Last", Email: "round-trip@example.com",
+					Password: "hash", IsAdmin: true, RoleID: env.roleID, LastLogin: &at,
+					CreatedAt: at, UpdatedAt: later, DeletedAt: &later,
+				})
+			}},
+			{"roles", func(t *testing.T) {
+				roundTrip(t, env, roleColumns, types.Role{ID: 1000, Name: "round-trip"})
+			}},
+			{"images", func(t *testing.T) {
+				roundTrip(t, env, imageColumns, types.Image{
+					ID: 1000, URL: "/media/1000/a.png", Filename: "a.png", StorageKey: "ab/cd.png",
+					Size: 1234, MimeType: "image/png", Width: 20, Height: 10,
+					CreatedAt: at, DeletedAt: &later,
+				})
+			}},
+			{"tags", func(t *testing.T) {
+				roundTrip(t, env, tagColumns, types.Tag{
+					ID: 1000, Name: "Tag", Slug: "round-trip", CreatedAt: at, UpdatedAt: later,
+				})
+			}},
+			{"categories", func(t *testing.T) {
+				roundTrip(t, env, categoryColumns, types.Category{
+					ID: 1000, Name: "Category", Slug: "round-trip", Description: "About",
+					CreatedAt: at, UpdatedAt: later,
+				})
+			}},
+			{"article_revisions", func(t *testing.T) {
+				roundTrip(t, env, revisionColumns, types.ArticleRevision{
+					ID: 1000, ArticleID: article.ID, Revision: 7, Title: "Title", Slug: "round-trip",
+					ShortDescription: "Short", Content: "Body", Status: types.ArticleStatusDraft,
+					EditorID: &userID, CreatedAt: at,
+				})
+			}},
+		}
+		for _, m := range mappings {
+			t.Run(m.name, m.run)
+		}
+	})
+}
+
+func TestCheckColumnsRejectsDrift(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, env *testEnv) {
+		drifted := columns[types.Tag]{
+			table: "tags",
+			cols: append(tagColumns.cols[:len(tagColumns.cols):len(tagColumns.cols)],
+				column[types.Tag]{"colour", func(t *types.Tag) any { return &t.Name }}),
+		}
+		err := checkColumns(env.db, drifted)
+		if err == nil || !strings.Contains(err.Error(), "tags columns do not match schema") {
+			t.Errorf("checkColumns = %v, want a schema mismatch", err)
+		}
+	})
+}
+
+// roundTrip checks the mapping c against its table, then inserts want
+// through the mapping and checks every column scans back as written.
+func roundTrip[T any](t *testing.T, env *testEnv, c columns[T], want T) {
+	t.Helper()
+	if err := checkColumns(env.db, c); err != nil {
+		t.Fatal(err)
+	}
+
+	values := make([]any, len(c.cols))
+	var id any
+	for i, target := range c.targets(&want) {
+		values[i] = targetValue(target)
+		if c.cols[i].name == "id" {
+			id = values[i]
+		}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	insert := "INSERT INTO " + c.table + " (" + c.list("") + ") VALUES (" + placeholders + ")"
+	if _, err := env.db.Exec(env.d.Rebind(insert), values...); err != nil {
+		t.Fatalf("inserting into %s: %v", c.table, err)
+	}
+
+	got, err := c.scan(env.db.QueryRow(env.d.Rebind("SELECT "+c.list("")+" FROM "+c.table+" WHERE id = ?"), id))
+	if err != nil {
+		t.Fatalf("scanning %s: %v", c.table, err)
+	}
+	for i, target := range c.targets(&got) {
+		if value := targetValue(target); !sameValue(value, values[i]) {
+			t.Errorf("%s.%s = %v, want %v", c.table, c.cols[i].name, value, values[i])
+		}
+	}
+}
+
+// targetValue is the value behind a scan target.
+func targetValue(target any) any {
+	switch v := target.(type) {
+	case nullString:
+		return *v.s
+	case nullTime:
+		return *v.t
+	}
+	return reflect.ValueOf(target).Elem().Interface()
+}
+
+// sameValue compares scanned values, treating times as equal when they are
+// the same instant whatever location the driver returned them in.
+func sameValue(got, want any) bool {
+	switch w := want.(type) {
+	case time.Time:
+		g, ok := got.(time.Time)
+		return ok && g.Equal(w)
+	case *time.Time:
+		g, ok := got.(*time.Time)
+		return ok && (g == nil) == (w == nil) && (w == nil || g.Equal(*w))
+	}
+	return reflect.DeepEqual(got, want)
+}
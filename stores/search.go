@@ -0,0 +1,327 @@
+package stores
+
+import (
+	"database/sql"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/types"
+	"unicode"
+)
+
+var ErrEmptySearch = errors.New("search query has no searchable words")
+
+// Matches are marked with control characters by the database and turned
+// into <mark> tags only after the text has been HTML-escaped.
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+type SearchStore struct {
+	conn
+	// fts is false for SQLite builds without FTS5, where the search index
+	// migration is left pending and search matches substrings instead.
+	fts bool
+}
+
+func NewSearchStore(db *sql.DB, d dialect.Dialect) *SearchStore {
+	s := &SearchStore{conn: newConn(db, d), fts: true}
+	if d == dialect.SQLite {
+		var count int
+		err := s.queryRow(`
+			SELECT COUNT(*) FROM sqlite_master
+			WHERE type = 'table' AND name = 'articles_fts' AND sqlite_compileoption_used('ENABLE_FTS5')`,
+		).Scan(&count)
+		s.fts = err == nil && count > 0
+	}
+	return s
+}
+
+// searchTerm is one word or quoted phrase of a search query. A prefix term
+// matches any word starting with its last word.
+type searchTerm struct {
+	words  []string
+	prefix bool
+}
+
+// parseSearchQuery splits q into terms. Quotes group words into a phrase
+// and a trailing * makes a word or phrase a prefix match. Anything that
+// isn't a letter or digit only separates words, so user input can never
+// produce a syntax error in the database's query language.
+func parseSearchQuery(q string) []searchTerm {
+	var terms []searchTerm
+	add := func(text string, prefix bool) {
+		words := strings.FieldsFunc(text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) > 0 {
+			terms = append(terms, searchTerm{words: words, prefix: prefix})
+		}
+	}
+
+	for {
+		q = strings.TrimLeftFunc(q, unicode.IsSpace)
+		if q == "" {
+			break
+		}
+
+		if q[0] == '"' {
+			end := strings.IndexByte(q[1:], '"')
+			if end < 0 {
+				add(q[1:], false)
+				break
+			}
+			phrase, rest := q[1:end+1], q[end+2:]
+			prefix := strings.HasPrefix(rest, "*")
+			add(phrase, prefix)
+			q = strings.TrimPrefix(rest, "*")
+			continue
+		}
+
+		end := strings.IndexFunc(q, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end < 0 {
+			end = len(q)
+		}
+		word := q[:end]
+		// Each whitespace-separated chunk is its own term, so "e-mail"
+		// searches for the phrase "e mail" rather than two loose words.
+		add(strings.TrimSuffix(word, "*"), strings.HasSuffix(word, "*"))
+		q = q[end:]
+	}
+	return terms
+}
+
+// fts5Query renders terms in SQLite FTS5 MATCH syntax. Terms are ANDed.
+func fts5Query(terms []searchTerm) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = `"` + strings.Join(term.words, " ") + `"`
+		if term.prefix {
+			parts[i] += " *"
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// tsQuery renders terms for Postgres to_tsquery.
+func tsQuery(terms []searchTerm) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		words := append([]string(nil), term.words...)
+		if term.prefix {
+			words[len(words)-1] += ":*"
+		}
+		parts[i] = strings.Join(words, " <-> ")
+		if len(words) > 1 {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+// markMatches HTML-escapes text from the search index and swaps the
+// match markers for <mark> tags.
+func markMatches(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, markStart, "<mark>")
+	return strings.ReplaceAll(text, markEnd, "</mark>")
+}
+
+// Search runs a ranked full-text search limited to what the viewer may see.
+func (s *SearchStore) Search(query types.SearchQuery) (types.Page[types.SearchResult], error) {
+	page := types.Page[types.SearchResult]{
+		Data:    []types.SearchResult{},
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	}
+
+	terms := parseSearchQuery(query.Query)
+	if len(terms) == 0 {
+		return page, ErrEmptySearch
+	}
+
+	var from, match, title, snippet, score string
+	var selectArgs, matchArgs []any
+	var matchArg any
+	switch {
+	case !s.fts:
+		from = `
+			FROM articles a
+			LEFT JOIN authors au ON a.author_id = au.id`
+		title = "a.title"
+		snippet = "COALESCE(a.short_description, '') || ' ' || a.content"
+		match, score, matchArgs, selectArgs = likeSearch(terms)
+	case s.dialect == dialect.Postgres:
+		from = `
+			FROM article_search
+			INNER JOIN articles a ON a.id = article_search.article_id
+			LEFT JOIN authors au ON a.author_id = au.id`
+		match = "article_search.document @@ to_tsquery('simple', ?)"
+		matchArg = tsQuery(terms)
+		title = "ts_headline('simple', a.title, to_tsquery('simple', ?), ?)"
+		snippet = "ts_headline('simple', COALESCE(a.short_description, '') || ' ' || a.content, to_tsquery('simple', ?), ?)"
+		score = "ts_rank(article_search.document, to_tsquery('simple', ?))"
+		selectArgs = []any{
+			matchArg, "StartSel=" + markStart + ", StopSel=" + markEnd + ", HighlightAll=true",
+			matchArg, "StartSel=" + markStart + ", StopSel=" + markEnd + `, MaxWords=24, MinWords=8, MaxFragments=2, FragmentDelimiter=" … "`,
+			matchArg,
+		}
+	default:
+		from = `
+			FROM articles_fts
+			INNER JOIN articles a ON a.id = articles_fts.rowid
+			LEFT JOIN authors au ON a.author_id = au.id`
+		match = "articles_fts MATCH ?"
+		matchArg = fts5Query(terms)
+		title = "highlight(articles_fts, 0, char(2), char(3))"
+		snippet = "snippet(articles_fts, 2, char(2), char(3), '…', 24)"
+		// bm25 is lower-is-better; title and author matches weigh more
+		// than body text.
+		score = "-bm25(articles_fts, 10.0, 4.0, 1.0, 4.0)"
+	}
+	if matchArgs == nil {
+		matchArgs = []any{matchArg}
+	}
+
+	where := []string{match, "a.deleted_at IS NULL"}
+	args := matchArgs
+	if clause, clauseArgs := visibility(query.Viewer); clause != "" {
+		where = append(where, clause)
+		args = append(args, clauseArgs...)
+	}
+
+	err := s.queryRow("SELECT COUNT(*)"+from+`
+		WHERE `+strings.Join(where, " AND "),
+		args...,
+	).Scan(&page.Total)
+	if err != nil {
+		return page, err
+	}
+
+	offset := (query.Page - 1) * query.PerPage
+	rows, err := s.query(`
+		SELECT `+articleColumns.list("a")+`, `+authorColumns.list("au")+`,
+			`+title+`, `+snippet+`, `+score+` AS score`+from+`
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY score DESC, a.id DESC
+		LIMIT ? OFFSET ?`,
+		append(append(selectArgs, args...), query.PerPage, offset)...,
+	)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	var articles []types.Article
+	for rows.Next() {
+		var result types.SearchResult
+		var author types.Author
+		dest := append(articleColumns.targets(&result.Article), authorColumns.targets(&author)...)
+		dest = append(dest, &result.Title, &result.Snippet, &result.Score)
+		if err := rows.Scan(dest...); err != nil {
+			return page, err
+		}
+		result.Article.Author = &author
+		renderMissingHTML(&result.Article)
+		if !s.fts {
+			pattern := termPattern(terms)
+			result.Title = markTerms(result.Title, pattern)
+			result.Snippet = markTerms(excerpt(result.Snippet, pattern, 24), pattern)
+		}
+		result.Title = markMatches(result.Title)
+		result.Snippet = markMatches(result.Snippet)
+		page.Data = append(page.Data, result)
+		articles = append(articles, result.Article)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+
+	if err := s.attachTerms(articles); err != nil {
+		return page, err
+	}
+	for i := range page.Data {
+		page.Data[i].Article = articles[i]
+	}
+
+	return page, nil
+}
+
+// likeSearch is the fallback for SQLite without FTS5: every term must
+// appear somewhere in the article as a substring, and the score weighs
+// where it appears like the FTS5 ranking does.
+func likeSearch(terms []searchTerm) (match, score string, matchArgs, scoreArgs []any) {
+	fields := []struct {
+		expr   string
+		weight string
+	}{
+		{"a.title", "10"},
+		{"COALESCE(a.short_description, '')", "4"},
+		{"a.content", "1"},
+		{"COALESCE(au.first_name || ' ' || au.last_name, '')", "4"},
+	}
+
+	var conditions, weights []string
+	for _, term := range terms {
+		pattern := "%" + strings.Join(term.words, " ") + "%"
+		var either []string
+		for _, field := range fields {
+			either = append(either, field.expr+" LIKE ?")
+			matchArgs = append(matchArgs, pattern)
+			weights = append(weights, "CASE WHEN "+field.expr+" LIKE ? THEN "+field.weight+" ELSE 0 END")
+			scoreArgs = append(scoreArgs, pattern)
+		}
+		conditions = append(conditions, "("+strings.Join(either, " OR ")+")")
+	}
+	return strings.Join(conditions, " AND "), "(" + strings.Join(weights, " + ") + ")", matchArgs, scoreArgs
+}
+
+// termPattern matches any of terms in text, ignoring case and treating
+// any run of non-word characters as the space between phrase words.
+func termPattern(terms []searchTerm) *regexp.Regexp {
+	alternatives := make([]string, len(terms))
+	for i, term := range terms {
+		words := make([]string, len(term.words))
+		for j, word := range term.words {
+			words[j] = regexp.QuoteMeta(word)
+		}
+		alternatives[i] = strings.Join(words, `[^\pL\pN]+`)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(alternatives, "|"))
+}
+
+// markTerms wraps each match of pattern in the match markers.
+func markTerms(text string, pattern *regexp.Regexp) string {
+	return pattern.ReplaceAllString(text, markStart+"$0"+markEnd)
+}
+
+// excerpt cuts text down to about words words around the first match of
+// pattern, like the snippets FTS5 produces.
+func excerpt(text string, pattern *regexp.Regexp, words int) string {
+	fields := strings.Fields(text)
+	if len(fields) <= words {
+		return strings.Join(fields, " ")
+	}
+
+	start := 0
+	for i, field := range fields {
+		if pattern.MatchString(field) {
+			start = max(0, i-words/3)
+			break
+		}
+	}
+	end := min(len(fields), start+words)
+	start = max(0, end-words)
+
+	out := strings.Join(fields[start:end], " ")
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(fields) {
+		out += "…"
+	}
+	return out
+}
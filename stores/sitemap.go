@@ -0,0 +1,116 @@
+package stores
+
+import (
+	"database/sql"
+	"strings"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/types"
+)
+
+// SitemapStore reads just the slugs and timestamps of public pages, so a
+// sitemap of tens of thousands of articles doesn't load their content.
+type SitemapStore struct {
+	conn
+}
+
+func NewSitemapStore(db *sql.DB, d dialect.Dialect) *SitemapStore {
+	return &SitemapStore{conn: newConn(db, d)}
+}
+
+// Fingerprint returns a value that changes whenever an article, author or
+// tag is created, edited, published, unpublished or deleted, which is much
+// cheaper to check than rebuilding the sitemap.
+func (s *SitemapStore) Fingerprint() (string, error) {
+	queries := []string{
+		"SELECT COUNT(*), MAX(updated_at), MAX(deleted_at) FROM articles",
+		"SELECT COUNT(*), MAX(updated_at), MAX(deleted_at) FROM authors",
+		"SELECT COUNT(*), MAX(updated_at), NULL FROM tags",
+	}
+
+	parts := make([]string, 0, 3*len(queries))
+	for _, query := range queries {
+		var count, updated, deleted sql.NullString
+		if err := s.queryRow(query).Scan(&count, &updated, &deleted); err != nil {
+			return "", err
+		}
+		parts = append(parts, count.String, updated.String, deleted.String)
+	}
+	return strings.Join(parts, "|"), nil
+}
+
+// Content lists the published articles, with their author and tags, and
+// the live authors.
+func (s *SitemapStore) Content() (types.SitemapContent, error) {
+	var content types.SitemapContent
+
+	live, liveArgs := visibility(types.Viewer{})
+	rows, err := s.query(`
+		SELECT a.id, a.slug, au.slug, a.updated_at
+		FROM articles a
+		INNER JOIN authors au ON a.author_id = au.id
+		WHERE a.deleted_at IS NULL AND `+live+`
+		ORDER BY a.id`,
+		liveArgs...,
+	)
+	if err != nil {
+		return content, err
+	}
+	defer rows.Close()
+
+	index := map[int64]int{}
+	for rows.Next() {
+		var id int64
+		var article types.SitemapArticle
+		if err := rows.Scan(&id, &article.Slug, &article.AuthorSlug, nullTime{&article.UpdatedAt}); err != nil {
+			return content, err
+		}
+		index[id] = len(content.Articles)
+		content.Articles = append(content.Articles, article)
+	}
+	if err := rows.Err(); err != nil {
+		return content, err
+	}
+
+	tagRows, err := s.query(`
+		SELECT at.article_id, t.slug
+		FROM article_tags at
+		INNER JOIN tags t ON t.id = at.tag_id
+		ORDER BY t.slug`)
+	if err != nil {
+		return content, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var id int64
+		var slug string
+		if err := tagRows.Scan(&id, &slug); err != nil {
+			return content, err
+		}
+		if i, ok := index[id]; ok {
+			content.Articles[i].TagSlugs = append(content.Articles[i].TagSlugs, slug)
+		}
+	}
+	if err := tagRows.Err(); err != nil {
+		return content, err
+	}
+
+	authorRows, err := s.query(`
+		SELECT slug, updated_at
+		FROM authors
+		WHERE deleted_at IS NULL
+		ORDER BY id`)
+	if err != nil {
+		return content, err
+	}
+	defer authorRows.Close()
+
+	for authorRows.Next() {
+		var author types.SitemapAuthor
+		if err := authorRows.Scan(&author.Slug, nullTime{&author.UpdatedAt}); err != nil {
+			return content, err
+		}
+		content.Authors = append(content.Authors, author)
+	}
+	return content, authorRows.Err()
+}
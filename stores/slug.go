@@ -0,0 +1,125 @@
+package stores
+
+import (
+	"errors"
+	"strings"
+	"test-ai-api/slug"
+)
+
+var (
+	ErrInvalidSlug = errors.New("slug must contain at least one letter or digit")
+	ErrSlugTaken   = errors.New("slug is already in use")
+)
+
+// slugScope is a table whose slug column shares a namespace with the slugs
+// being generated. owner is the column holding the id of the row that owns
+// the slug, so a row never collides with its own slug.
+type slugScope struct {
+	table string
+	owner string
+}
+
+var (
+	articleSlugScopes = []slugScope{{"articles", "id"}, {"slug_redirects", "article_id"}}
+	authorSlugScopes  = []slugScope{{"authors", "id"}}
+)
+
+// takenSlugs returns every slug in scopes equal to base or base-N that is
+// owned by a row other than ownerID. A long base is trimmed to make room
+// for -N, so suffixed slugs are matched against each of its stems.
+// Soft-deleted rows count since they still hold the UNIQUE constraint.
+func (c conn) takenSlugs(scopes []slugScope, base string, ownerID int64) (map[string]bool, error) {
+	match := []string{"slug = ?"}
+	matchArgs := []any{base}
+	for _, stem := range slug.Stems(base) {
+		match = append(match, "slug LIKE ?")
+		matchArgs = append(matchArgs, stem+"-%")
+	}
+
+	var queries []string
+	var args []any
+	for _, scope := range scopes {
+		queries = append(queries, "SELECT slug FROM "+scope.table+" WHERE ("+strings.Join(match, " OR ")+") AND "+scope.owner+" <> ?")
+		args = append(append(args, matchArgs...), ownerID)
+	}
+
+	rows, err := c.query(strings.Join(queries, " UNION "), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taken := map[string]bool{}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		taken[s] = true
+	}
+	return taken, rows.Err()
+}
+
+// uniqueSlug derives a slug from source that no other row is using yet.
+func (c conn) uniqueSlug(scopes []slugScope, source, fallback string, ownerID int64) (string, error) {
+	base := slug.Make(source)
+	if base == "" {
+		base = fallback
+	}
+
+	taken, err := c.takenSlugs(scopes, base, ownerID)
+	if err != nil {
+		return "", err
+	}
+	return slug.Next(base, taken), nil
+}
+
+// claimSlug normalises a slug chosen by the client and checks that it is
+// free, without adding a suffix.
+func (c conn) claimSlug(scopes []slugScope, requested string, ownerID int64) (string, error) {
+	s := slug.Make(requested)
+	if s == "" {
+		return "", ErrInvalidSlug
+	}
+
+	taken, err := c.takenSlugs(scopes, s, ownerID)
+	if err != nil {
+		return "", err
+	}
+	if taken[s] {
+		return "", ErrSlugTaken
+	}
+	return s, nil
+}
+
+// insertWithSlug runs insert against c with a fresh unique slug. When a
+// concurrent request claims the slug between the lookup and the insert,
+// the next suffix is tried rather than the same slug again. Each attempt
+// runs in a savepoint, so a collision doesn't abort a surrounding
+// transaction.
+func (c conn) insertWithSlug(scopes []slugScope, source, fallback string, insert func(tx conn, slug string) (int64, error)) (int64, error) {
+	base := slug.Make(source)
+	if base == "" {
+		base = fallback
+	}
+	taken, err := c.takenSlugs(scopes, base, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	const attempts = 10
+	for attempt := 1; ; attempt++ {
+		s := slug.Next(base, taken)
+		var id int64
+		err := c.savepoint("insert_slug", func() error {
+			var err error
+			id, err = insert(c, s)
+			return err
+		})
+		if err != nil && attempt < attempts && c.dialect.IsUniqueViolation(err) {
+			taken[s] = true
+			continue
+		}
+		return id, err
+	}
+}
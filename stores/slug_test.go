@@ -0,0 +1,60 @@
+package stores
+
+import (
+	"strings"
+	"sync"
+	"test-ai-api/slug"
+	"test-ai-api/types"
+	"testing"
+)
+
+// longTitle slugs to 78 bytes: -2 to -9 fit after it, but from -10 the
+// last word is trimmed to make room.
+var longTitle = strings.Repeat("Lorem ipsum ", 5) + "dolor sit adipisci"
+
+func TestLongTitleSlugs(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, env *testEnv) {
+		author := env.author(t, "Long", "Winded")
+		seen := map[string]bool{}
+		for range 15 {
+			article := env.article(t, author.ID, longTitle)
+			if seen[article.Slug] || len(article.Slug) > slug.MaxLength {
+				t.Fatalf("slug %q repeated or too long", article.Slug)
+			}
+			seen[article.Slug] = true
+		}
+	})
+}
+
+func TestConcurrentDuplicateTitles(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, env *testEnv) {
+		author := env.author(t, "Same", "Time")
+		for _, title := range []string{"Breaking news", longTitle} {
+			const writers = 8
+			slugs := make([]string, writers)
+			errs := make([]error, writers)
+
+			var wg sync.WaitGroup
+			for i := range writers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					article, err := env.articles.Create(types.ArticleCreate{Title: title, Content: "Body"}, author.ID)
+					slugs[i], errs[i] = article.Slug, err
+				}()
+			}
+			wg.Wait()
+
+			seen := map[string]bool{}
+			for i := range writers {
+				if errs[i] != nil {
+					t.Fatalf("Create %q: %v", title, errs[i])
+				}
+				if seen[slugs[i]] {
+					t.Fatalf("slug %q given out twice", slugs[i])
+				}
+				seen[slugs[i]] = true
+			}
+		}
+	})
+}
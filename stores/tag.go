@@ -0,0 +1,28 @@
+package stores
+
+import (
+	"database/sql"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/types"
+)
+
+type TagStore struct {
+	termStore[types.Tag]
+}
+
+func NewTagStore(db *sql.DB, d dialect.Dialect) *TagStore {
+	return &TagStore{termStore[types.Tag]{
+		conn:     newConn(db, d),
+		term:     tagTerm,
+		cols:     tagColumns,
+		setCount: func(t *types.Tag, n int) { t.ArticleCount = &n },
+	}}
+}
+
+func (s *TagStore) Create(tag types.TagCreate) (types.Tag, error) {
+	return s.create(tag.Name, tag.Slug)
+}
+
+func (s *TagStore) Update(id int64, tag types.TagUpdate) (types.Tag, error) {
+	return s.update(id, tag.Name, tag.Slug)
+}
@@ -0,0 +1,185 @@
+package stores
+
+import (
+	"strings"
+	"test-ai-api/types"
+	"time"
+)
+
+// termStore holds what tags and categories share: CRUD over the term's
+// table, slugs and the count of published articles carrying each term.
+// The stores built on it only add their own columns.
+type termStore[T any] struct {
+	conn
+	term     articleTerm
+	cols     columns[T]
+	setCount func(*T, int)
+}
+
+// termValue is a column a particular taxonomy adds to its table.
+type termValue struct {
+	column string
+	value  any
+}
+
+func (s *termStore[T]) slugScopes() []slugScope {
+	return []slugScope{{s.term.table, "id"}}
+}
+
+// selectWithCount lists terms with the number of published, live articles
+// carrying each one. It takes the published status and the current time.
+func (s *termStore[T]) selectWithCount() string {
+	return `
+		SELECT ` + s.cols.list("t") + `, COUNT(a.id)
+		FROM ` + s.term.table + ` t
+		LEFT JOIN ` + s.term.link + ` l ON l.` + s.term.column + ` = t.id
+		LEFT JOIN articles a ON a.id = l.article_id
+			AND a.status = ? AND a.published_at <= ? AND a.deleted_at IS NULL`
+}
+
+func (s *termStore[T]) scanWithCount(row rowScanner) (T, error) {
+	var term T
+	var count int
+	if err := row.Scan(append(s.cols.targets(&term), &count)...); err != nil {
+		var zero T
+		return zero, err
+	}
+	s.setCount(&term, count)
+	return term, nil
+}
+
+// create inserts a term named name. A requested slug is used as given or
+// rejected if taken; otherwise one is derived from the name.
+func (s *termStore[T]) create(name, requested string, extra ...termValue) (T, error) {
+	cols := []string{"name", "slug", "created_at", "updated_at"}
+	for _, v := range extra {
+		cols = append(cols, v.column)
+	}
+	insert := func(tx conn, slug string) (int64, error) {
+		args := []any{name, slug, time.Now(), time.Now()}
+		for _, v := range extra {
+			args = append(args, v.value)
+		}
+		return tx.insert(`
+			INSERT INTO `+s.term.table+` (`+strings.Join(cols, ", ")+`)
+			VALUES (`+placeholders(len(cols))+`)`,
+			args...,
+		)
+	}
+
+	var id int64
+	var err error
+	if requested != "" {
+		var slug string
+		if slug, err = s.claimSlug(s.slugScopes(), requested, 0); err != nil {
+			var zero T
+			return zero, err
+		}
+		id, err = insert(s.conn, slug)
+		if s.dialect.IsUniqueViolation(err) {
+			err = ErrSlugTaken
+		}
+	} else {
+		id, err = s.insertWithSlug(s.slugScopes(), name, s.term.fallback, insert)
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return s.GetByID(id)
+}
+
+func (s *termStore[T]) GetByID(id int64) (T, error) {
+	return s.scanWithCount(s.queryRow(s.selectWithCount()+`
+		WHERE t.id = ?
+		GROUP BY `+s.cols.list("t"),
+		types.ArticleStatusPublished, time.Now(), id,
+	))
+}
+
+func (s *termStore[T]) GetBySlug(slug string) (T, error) {
+	return s.scanWithCount(s.queryRow(s.selectWithCount()+`
+		WHERE t.slug = ?
+		GROUP BY `+s.cols.list("t"),
+		types.ArticleStatusPublished, time.Now(), slug,
+	))
+}
+
+// GetAll lists every term alphabetically with its article count.
+func (s *termStore[T]) GetAll() ([]T, error) {
+	rows, err := s.query(s.selectWithCount()+`
+		GROUP BY `+s.cols.list("t")+`
+		ORDER BY t.name ASC, t.id ASC`,
+		types.ArticleStatusPublished, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := []T{}
+	for rows.Next() {
+		term, err := s.scanWithCount(rows)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
+// update renames the term. The slug only changes when one is given
+// explicitly, so existing links to the term keep working.
+func (s *termStore[T]) update(id int64, name, requested string, extra ...termValue) (T, error) {
+	var zero T
+	var slug string
+	if err := s.queryRow("SELECT slug FROM "+s.term.table+" WHERE id = ?", id).Scan(&slug); err != nil {
+		return zero, err
+	}
+	if requested != "" {
+		var err error
+		if slug, err = s.claimSlug(s.slugScopes(), requested, id); err != nil {
+			return zero, err
+		}
+	}
+
+	var set assignments
+	set.add("name", name)
+	set.add("slug", slug)
+	for _, v := range extra {
+		set.add(v.column, v.value)
+	}
+	set.add("updated_at", time.Now())
+
+	result, err := s.exec(`
+		UPDATE `+s.term.table+` SET `+set.clause()+`
+		WHERE id = ?`,
+		append(set.args, id)...,
+	)
+	if s.dialect.IsUniqueViolation(err) {
+		return zero, ErrSlugTaken
+	}
+	if err != nil {
+		return zero, err
+	}
+	if err := requireRow(result); err != nil {
+		return zero, err
+	}
+
+	return s.GetByID(id)
+}
+
+// Delete removes the term and its links to articles.
+func (s *termStore[T]) Delete(id int64) error {
+	return s.inTx(func(tx conn) error {
+		if _, err := tx.exec("DELETE FROM "+s.term.link+" WHERE "+s.term.column+" = ?", id); err != nil {
+			return err
+		}
+		result, err := tx.exec("DELETE FROM "+s.term.table+" WHERE id = ?", id)
+		if err != nil {
+			return err
+		}
+		return requireRow(result)
+	})
+}
@@ -0,0 +1,204 @@
+package stores
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"test-ai-api/init/db"
+	"test-ai-api/init/db/dialect"
+	"test-ai-api/storage"
+	"test-ai-api/types"
+	"testing"
+	"time"
+)
+
+// postgresDSNEnv names the variable pointing the tests at a Postgres
+// database. Each test runs in a schema of its own, dropped afterwards.
+const postgresDSNEnv = "TEST_POSTGRES_DSN"
+
+// testDialects lists the databases to run against: SQLite always,
+// Postgres when postgresDSNEnv is set.
+func testDialects() []dialect.Dialect {
+	dialects := []dialect.Dialect{dialect.SQLite}
+	if os.Getenv(postgresDSNEnv) != "" {
+		dialects = append(dialects, dialect.Postgres)
+	}
+	return dialects
+}
+
+// forEachDialect runs fn once per test database, each freshly migrated.
+func forEachDialect(t *testing.T, fn func(t *testing.T, env *testEnv)) {
+	t.Helper()
+	for _, d := range testDialects() {
+		t.Run(d.Name(), func(t *testing.T) {
+			fn(t, newTestEnv(t, d))
+		})
+	}
+}
+
+// openTestDB creates an empty, migrated database that is removed when the
+// test ends.
+func openTestDB(t *testing.T, d dialect.Dialect) *sql.DB {
+	t.Helper()
+
+	cfg := db.DefaultConfig()
+	cfg.Driver = d.Driver()
+	if d == dialect.SQLite {
+		cfg.DSN = filepath.Join(t.TempDir(), "test.db")
+	} else {
+		cfg.DSN = postgresSchemaDSN(t, os.Getenv(postgresDSNEnv))
+	}
+
+	conn, err := db.Open(cfg)
+	if err != nil {
+		t.Fatalf("opening %s test database: %v", d.Name(), err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// postgresSchemaDSN creates a throwaway schema and returns dsn with it as
+// the search path.
+func postgresSchemaDSN(t *testing.T, dsn string) string {
+	t.Helper()
+
+	b := make([]byte, 6)
+	rand.Read(b)
+	schema := "test_" + hex.EncodeToString(b)
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatalf("creating schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return dsn + " search_path=" + schema
+}
+
+// testEnv holds every store over one test database.
+type testEnv struct {
+	db         *sql.DB
+	d          dialect.Dialect
+	blobs      storage.BlobStorage
+	articles   *ArticleStore
+	authors    *AuthorStore
+	users      *UserStore
+	tags       *TagStore
+	categories *CategoryStore
+	images     *ImageStore
+	sitemaps   *SitemapStore
+	search     *SearchStore
+	roleID     int64
+}
+
+func newTestEnv(t *testing.T, d dialect.Dialect) *testEnv {
+	t.Helper()
+
+	conn := openTestDB(t, d)
+	blobs, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleID, err := d.InsertID(conn, "INSERT INTO roles (name) VALUES (?)", "author")
+	if err != nil {
+		t.Fatalf("inserting role: %v", err)
+	}
+
+	return &testEnv{
+		db:         conn,
+		d:          d,
+		blobs:      blobs,
+		articles:   NewArticleStore(conn, d),
+		authors:    NewAuthorStore(conn, d),
+		users:      NewUserStore(conn, d),
+		tags:       NewTagStore(conn, d),
+		categories: NewCategoryStore(conn, d),
+		images:     NewImageStore(conn, d, blobs),
+		sitemaps:   NewSitemapStore(conn, d),
+		search:     NewSearchStore(conn, d),
+		roleID:     roleID,
+	}
+}
+
+// user inserts a user with the given email and returns its id.
+func (env *testEnv) user(t *testing.T, email string) int64 {
+	t.Helper()
+	id, err := env.d.InsertID(env.db, `
+		INSERT INTO users (first_name, last_name, email, password, role_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		"Test", "User", email, "x", env.roleID, time.Now(), time.Now(),
+	)
+	if err != nil {
+		t.Fatalf("inserting user %s: %v", email, err)
+	}
+	return id
+}
+
+// author creates an author, with a user of its own, named first last.
+func (env *testEnv) author(t *testing.T, first, last string) types.Author {
+	t.Helper()
+	userID := env.user(t, strings.ToLower(first+"."+last+"."+randomSuffix())+"@example.com")
+	author, err := env.authors.Create(types.AuthorCreate{FirstName: first, LastName: last}, userID)
+	if err != nil {
+		t.Fatalf("creating author: %v", err)
+	}
+	return author
+}
+
+// article creates a draft article by author.
+func (env *testEnv) article(t *testing.T, authorID int64, title string) types.Article {
+	t.Helper()
+	article, err := env.articles.Create(types.ArticleCreate{Title: title, Content: "Body of " + title}, authorID)
+	if err != nil {
+		t.Fatalf("creating article %q: %v", title, err)
+	}
+	return article
+}
+
+// publish moves an article through review to published, as its author.
+func (env *testEnv) publish(t *testing.T, id int64) types.Article {
+	t.Helper()
+	article, err := env.articles.GetByID(id)
+	if err != nil {
+		t.Fatalf("loading article %d: %v", id, err)
+	}
+	author, err := env.authors.GetByID(article.AuthorID)
+	if err != nil {
+		t.Fatalf("loading author %d: %v", article.AuthorID, err)
+	}
+	if _, err := env.articles.Transition(id, types.ArticleStatusInReview, nil, author.UserID); err != nil {
+		t.Fatalf("submitting article %d: %v", id, err)
+	}
+	article, err = env.articles.Transition(id, types.ArticleStatusPublished, nil, author.UserID)
+	if err != nil {
+		t.Fatalf("publishing article %d: %v", id, err)
+	}
+	return article
+}
+
+func randomSuffix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -2,77 +2,78 @@ package stores
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"test-ai-api/init/db/dialect"
 	"test-ai-api/types"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrEmailTaken = errors.New("email is already in use")
+
 type UserStore struct {
-	db *sql.DB
+	conn
+}
+
+func NewUserStore(db *sql.DB, d dialect.Dialect) *UserStore {
+	return &UserStore{conn: newConn(db, d)}
 }
 
-func NewUserStore(db *sql.DB) *UserStore {
-	return &UserStore{db: db}
+var userSelect = `
+		SELECT ` + userColumns.list("u") + `, ` + roleColumns.list("r") + `
+		FROM users u
+		INNER JOIN roles r ON u.role_id = r.id`
+
+func scanUser(row rowScanner) (types.User, error) {
+	var user types.User
+	dest := append(userColumns.targets(&user), roleColumns.targets(&user.Role)...)
+	if err := row.Scan(dest...); err != nil {
+		return types.User{}, err
+	}
+	return user, nil
 }
 
 func (s *UserStore) GetAll(limit int, offset int) ([]types.User, error) {
-	users, err := s.db.Query("SELECT * FROM users WHERE deleted_at IS NULL LIMIT ? OFFSET ?", limit, offset)
+	rows, err := s.query(userSelect+`
+		WHERE u.deleted_at IS NULL
+		LIMIT ? OFFSET ?`,
+		limit, offset,
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer users.Close()
+	defer rows.Close()
 
 	usersList := []types.User{}
-	for users.Next() {
-		var user types.User
-		err := users.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.LastLogin, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
+	for rows.Next() {
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
 		usersList = append(usersList, user)
 	}
 
-	return usersList, nil
+	return usersList, rows.Err()
 }
 
 func (s *UserStore) GetByEmail(email string) (types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE email = ?", email)
-	if err != nil {
-		return types.User{}, err
-	}
-	defer rows.Close()
-
-	var user types.User
-	err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.LastLogin, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt)
-	if err != nil {
-		return types.User{}, err
-	}
-
-	return user, nil
+	return scanUser(s.queryRow(userSelect+`
+		WHERE u.email = ? AND u.deleted_at IS NULL`,
+		email,
+	))
 }
 
 func (s *UserStore) GetByID(id int64) (types.User, error) {
-	var user types.User
-	var role types.Role
-	err := s.db.QueryRow(`
-		SELECT u.*, r.id, r.name 
-		FROM users u 
-		INNER JOIN roles r ON u.role_id = r.id 
+	return scanUser(s.queryRow(userSelect+`
 		WHERE u.id = ? AND u.deleted_at IS NULL`,
 		id,
-	).Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password,
-		&user.IsAdmin, &user.RoleID, &user.LastLogin, &user.CreatedAt, &user.UpdatedAt,
-		&user.DeletedAt, &role.ID, &role.Name)
-	if err != nil {
-		return types.User{}, err
-	}
-	user.Role = role
-	return user, nil
+	))
 }
 
 func (s *UserStore) Create(user types.User) (types.User, error) {
-	rows, err := s.db.Query("INSERT INTO users (first_name, last_name, email, password) VALUES (?, ?, ?, ?)", user.FirstName, user.LastName, user.Email, user.Password)
+	rows, err := s.query("INSERT INTO users (first_name, last_name, email, password) VALUES (?, ?, ?, ?)", user.FirstName, user.LastName, user.Email, user.Password)
 	if err != nil {
 		return types.User{}, err
 	}
@@ -82,7 +83,7 @@ func (s *UserStore) Create(user types.User) (types.User, error) {
 }
 
 func (s *UserStore) Update(user types.User) (types.User, error) {
-	rows, err := s.db.Query("UPDATE users SET first_name = ?, last_name = ?, email = ? WHERE id = ?", user.FirstName, user.LastName, user.Email, user.ID)
+	rows, err := s.query("UPDATE users SET first_name = ?, last_name = ?, email = ? WHERE id = ?", user.FirstName, user.LastName, user.Email, user.ID)
 	if err != nil {
 		return types.User{}, err
 	}
@@ -91,8 +92,37 @@ func (s *UserStore) Update(user types.User) (types.User, error) {
 	return user, nil
 }
 
+// Patch writes only the profile fields set in patch.
+func (s *UserStore) Patch(id int64, patch types.UserPatch) (types.User, error) {
+	var set assignments
+	setIf(&set, "first_name", patch.FirstName)
+	setIf(&set, "last_name", patch.LastName)
+	setIf(&set, "email", patch.Email)
+	if set.empty() {
+		return s.GetByID(id)
+	}
+	set.add("updated_at", time.Now())
+
+	result, err := s.exec(`
+		UPDATE users SET `+set.clause()+`
+		WHERE id = ? AND deleted_at IS NULL`,
+		append(set.args, id)...,
+	)
+	if s.dialect.IsUniqueViolation(err) {
+		return types.User{}, ErrEmailTaken
+	}
+	if err != nil {
+		return types.User{}, err
+	}
+	if err := requireRow(result); err != nil {
+		return types.User{}, err
+	}
+
+	return s.GetByID(id)
+}
+
 func (s *UserStore) Delete(id int) error {
-	_, err := s.db.Exec("DELETE FROM users WHERE id = ?", id)
+	_, err := s.exec("DELETE FROM users WHERE id = ?", id)
 	if err != nil {
 		return err
 	}
@@ -107,7 +137,7 @@ func (s *UserStore) Register(ur types.UserRegister) (types.User, error) {
 		return types.User{}, err
 	}
 
-	result, err := s.db.Exec(
+	id, err := s.insert(
 		"INSERT INTO users (first_name, last_name, email, password) VALUES (?, ?, ?, ?)",
 		ur.FirstName, ur.LastName, ur.Email, string(hashedPassword),
 	)
@@ -115,11 +145,6 @@ func (s *UserStore) Register(ur types.UserRegister) (types.User, error) {
 		return types.User{}, err
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return types.User{}, err
-	}
-
 	user, err := s.GetByID(id)
 	if err != nil {
 		return types.User{}, err
@@ -129,18 +154,7 @@ func (s *UserStore) Register(ur types.UserRegister) (types.User, error) {
 }
 
 func (s *UserStore) Login(email, password string) (types.User, error) {
-	var user types.User
-	var role types.Role
-	var hashedPassword string
-
-	err := s.db.QueryRow(
-		`SELECT users.id, users.first_name, users.last_name, users.email, users.password, roles.id, roles.name
-		FROM users 
-		INNER JOIN roles ON users.role_id = roles.id
-		WHERE users.email = ? AND users.deleted_at IS NULL`,
-		email,
-	).Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &hashedPassword, &role.ID, &role.Name)
-
+	user, err := s.GetByEmail(email)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return types.User{}, fmt.Errorf("invalid credentials")
@@ -148,10 +162,9 @@ func (s *UserStore) Login(email, password string) (types.User, error) {
 		return types.User{}, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		return types.User{}, fmt.Errorf("invalid credentials")
 	}
-	user.Role = role
 
 	return user, nil
 }
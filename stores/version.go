@@ -0,0 +1,26 @@
+package stores
+
+import "fmt"
+
+// VersionConflictError is returned when an update was made against a
+// version of the row that has since changed. Current is the version the
+// row is at now.
+type VersionConflictError struct {
+	Current int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("row has changed, current version is %d", e.Current)
+}
+
+// versionConflict explains why a version-guarded UPDATE on table matched no
+// rows: either the row is gone (sql.ErrNoRows) or someone else updated it
+// first.
+func (c conn) versionConflict(table string, id int64) error {
+	var current int64
+	err := c.queryRow("SELECT version FROM "+table+" WHERE id = ? AND deleted_at IS NULL", id).Scan(&current)
+	if err != nil {
+		return err
+	}
+	return &VersionConflictError{Current: current}
+}
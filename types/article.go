@@ -8,26 +8,99 @@ type Article struct {
 	Slug             string     `json:"slug"`
 	ShortDescription string     `json:"short_description"`
 	Content          string     `json:"content"`
+	ContentHTML      string     `json:"content_html"`
 	Status           string     `json:"status"`
 	AuthorID         int64      `json:"author_id"`
 	Author           *Author    `json:"author,omitempty"`
+	Tags             []Tag      `json:"tags"`
+	Categories       []Category `json:"categories"`
 	PublishedAt      *time.Time `json:"published_at,omitempty"`
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
 	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+	Version          int64      `json:"version"`
+}
+
+type ArticleTransition struct {
+	PublishedAt *time.Time `json:"published_at,omitempty"`
 }
 
 type ArticleCreate struct {
-	Title            string `json:"title"`
-	ShortDescription string `json:"short_description"`
-	Content          string `json:"content"`
-	Status           string `json:"status"`
+	Title            string   `json:"title"`
+	ShortDescription string   `json:"short_description"`
+	Content          string   `json:"content"`
+	Status           string   `json:"status"`
+	Tags             []string `json:"tags,omitempty"`
+	Categories       []string `json:"categories,omitempty"`
 }
 
 type ArticleUpdate struct {
 	Title            string     `json:"title"`
+	Slug             string     `json:"slug,omitempty"`
 	ShortDescription string     `json:"short_description"`
 	Content          string     `json:"content"`
 	Status           string     `json:"status"`
 	PublishedAt      *time.Time `json:"published_at,omitempty"`
+	// Tags and Categories hold slugs. Leaving them out keeps the current
+	// ones; an empty list clears them.
+	Tags       []string `json:"tags,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// ArticlePatch lists the fields a partial update changes; nil fields are
+// left as they are.
+type ArticlePatch struct {
+	Title            *string
+	Slug             *string
+	ShortDescription *string
+	Content          *string
+	Status           *string
+	PublishedAt      *time.Time
+	Tags             []string
+	Categories       []string
+}
+
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusInReview  = "in_review"
+	ArticleStatusScheduled = "scheduled"
+	ArticleStatusPublished = "published"
+	ArticleStatusArchived  = "archived"
+)
+
+const (
+	ArticleSortNewest = "newest"
+	ArticleSortOldest = "oldest"
+	ArticleSortTitle  = "title"
+)
+
+// ArticleFilter narrows and orders an article listing. Either Page or
+// Cursor selects the window; Cursor takes precedence when both are set.
+type ArticleFilter struct {
+	Status        string
+	AuthorSlug    string
+	Tag           string
+	Category      string
+	PublishedFrom *time.Time
+	PublishedTo   *time.Time
+	Sort          string
+	Page          int
+	PerPage       int
+	Cursor        string
+	Viewer        Viewer
+}
+
+// ArticleRevision is a full snapshot of an article's editable fields taken
+// each time it is saved.
+type ArticleRevision struct {
+	ID               int64     `json:"id"`
+	ArticleID        int64     `json:"article_id"`
+	Revision         int       `json:"revision"`
+	Title            string    `json:"title"`
+	Slug             string    `json:"slug"`
+	ShortDescription string    `json:"short_description"`
+	Content          string    `json:"content"`
+	Status           string    `json:"status"`
+	EditorID         *int64    `json:"editor_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
 }
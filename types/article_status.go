@@ -0,0 +1,30 @@
+package types
+
+// articleTransitions lists, for each status, the statuses an article may move
+// to next. The happy path is draft -> in_review -> scheduled -> published ->
+// archived; the remaining edges send work back to draft.
+var articleTransitions = map[string][]string{
+	ArticleStatusDraft:     {ArticleStatusInReview},
+	ArticleStatusInReview:  {ArticleStatusDraft, ArticleStatusScheduled, ArticleStatusPublished},
+	ArticleStatusScheduled: {ArticleStatusDraft, ArticleStatusPublished},
+	ArticleStatusPublished: {ArticleStatusDraft, ArticleStatusArchived},
+	ArticleStatusArchived:  {ArticleStatusDraft},
+}
+
+func IsArticleStatus(status string) bool {
+	_, ok := articleTransitions[status]
+	return ok
+}
+
+func ArticleTransitions(from string) []string {
+	return articleTransitions[from]
+}
+
+func CanTransitionArticle(from, to string) bool {
+	for _, next := range articleTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
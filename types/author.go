@@ -13,6 +13,7 @@ type Author struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Version   int64      `json:"version"`
 }
 
 type AuthorCreate struct {
@@ -26,3 +27,11 @@ type AuthorUpdate struct {
 	LastName  string `json:"last_name"`
 	Bio       string `json:"bio"`
 }
+
+// AuthorPatch lists the fields a partial update changes; nil fields are
+// left as they are.
+type AuthorPatch struct {
+	FirstName *string
+	LastName  *string
+	Bio       *string
+}
@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+type Category struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// ArticleCount is only filled in by listings: the number of published
+	// articles in the category.
+	ArticleCount *int `json:"article_count,omitempty"`
+}
+
+type CategoryCreate struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug,omitempty"`
+	Description string `json:"description"`
+}
+
+type CategoryUpdate struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug,omitempty"`
+	Description string `json:"description"`
+}
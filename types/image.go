@@ -2,13 +2,42 @@ package types
 
 import "time"
 
+// Image is either a link to an image hosted elsewhere, with only URL set,
+// or an uploaded file kept in blob storage under StorageKey.
 type Image struct {
-	ID        int64      `json:"id"`
-	URL       string     `json:"url"`
-	CreatedAt time.Time  `json:"created_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ID         int64  `json:"id"`
+	URL        string `json:"url"`
+	Filename   string `json:"filename,omitempty"`
+	StorageKey string `json:"-"`
+	Size       int64  `json:"size,omitempty"`
+	MimeType   string `json:"mime_type,omitempty"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	// Variants are smaller copies of an upload, narrowest first, and
+	// SrcSet lists them with the original for an <img srcset>.
+	Variants  []ImageVariant `json:"variants,omitempty"`
+	SrcSet    string         `json:"srcset,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt *time.Time     `json:"deleted_at,omitempty"`
+}
+
+// ImageVariant is a resized copy of an uploaded image. It is generated the
+// first time its URL is requested.
+type ImageVariant struct {
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
 }
 
 type ImageCreate struct {
 	URL string `json:"url"`
 }
+
+// ImageUpload describes an uploaded file, checked and ready to store.
+type ImageUpload struct {
+	Filename string
+	Size     int64
+	MimeType string
+	Width    int
+	Height   int
+}
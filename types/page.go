@@ -0,0 +1,17 @@
+package types
+
+type PageLinks struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// Page is the envelope returned by list endpoints.
+type Page[T any] struct {
+	Data       []T       `json:"data"`
+	Total      int       `json:"total"`
+	Page       int       `json:"page,omitempty"`
+	PerPage    int       `json:"per_page"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
+	Links      PageLinks `json:"links"`
+}
@@ -0,0 +1,20 @@
+package types
+
+// SearchQuery is a full-text search over articles. Query accepts plain
+// words, "quoted phrases" and prefix* terms; every term must match.
+type SearchQuery struct {
+	Query   string
+	Page    int
+	PerPage int
+	Viewer  Viewer
+}
+
+// SearchResult is a matching article with the matches marked up. Title and
+// Snippet are HTML-escaped with matches wrapped in <mark>; Score is higher
+// for better matches.
+type SearchResult struct {
+	Article Article `json:"article"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+}
@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+// SitemapArticle is the little the sitemap needs to know about a published
+// article.
+type SitemapArticle struct {
+	Slug       string
+	AuthorSlug string
+	TagSlugs   []string
+	UpdatedAt  time.Time
+}
+
+// SitemapAuthor is an author page for the sitemap.
+type SitemapAuthor struct {
+	Slug      string
+	UpdatedAt time.Time
+}
+
+// SitemapContent lists every public page the sitemap covers.
+type SitemapContent struct {
+	Articles []SitemapArticle
+	Authors  []SitemapAuthor
+}
@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+type Tag struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// ArticleCount is only filled in by listings: the number of published
+	// articles carrying the tag.
+	ArticleCount *int `json:"article_count,omitempty"`
+}
+
+type TagCreate struct {
+	Name string `json:"name"`
+	Slug string `json:"slug,omitempty"`
+}
+
+type TagUpdate struct {
+	Name string `json:"name"`
+	Slug string `json:"slug,omitempty"`
+}
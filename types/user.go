@@ -23,3 +23,11 @@ type UserRegister struct {
 	Email     string `json:"email"`
 	Password  string `json:"password"`
 }
+
+// UserPatch lists the profile fields a partial update changes; nil fields
+// are left as they are.
+type UserPatch struct {
+	FirstName *string
+	LastName  *string
+	Email     *string
+}
@@ -0,0 +1,9 @@
+package types
+
+// Viewer identifies who is reading content so stores can decide which
+// articles are visible. The zero value is an anonymous reader.
+type Viewer struct {
+	UserID   int64
+	AuthorID int64
+	IsAdmin  bool
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"strings"
 	"test-ai-api/types"
 	"time"
 
@@ -13,8 +12,9 @@ import (
 )
 
 type Claims struct {
-	UserID int64  `json:"user_id"`
-	Role   string `json:"role"`
+	UserID  int64  `json:"user_id"`
+	Role    string `json:"role"`
+	IsAdmin bool   `json:"is_admin"`
 	jwt.RegisteredClaims
 }
 
@@ -23,10 +23,11 @@ func GenerateJWT(user types.User) (string, error) {
 	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"role":    user.Role.Name,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // 24 hour expiration
+		"user_id":  user.ID,
+		"email":    user.Email,
+		"role":     user.Role.Name,
+		"is_admin": user.IsAdmin || user.Role.Name == "admin",
+		"exp":      time.Now().Add(time.Hour * 24).Unix(), // 24 hour expiration
 	})
 
 	tokenString, err := token.SignedString(secretKey)
@@ -63,9 +64,3 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 	w.Write(response)
 }
-
-func GenerateSlug(input string) string {
-	input = strings.ToLower(input)
-	input = strings.ReplaceAll(input, " ", "-")
-	return input
-}